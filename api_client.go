@@ -1,12 +1,17 @@
 package api_client
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	// "time"
 )
 
+// AddHeadersRoundtripper adds headers to the requests of an HTTP client. Clients add
+// headers with HeadersMiddleware instead.
 type AddHeadersRoundtripper struct {
 	Headers http.Header
 	Nested  http.RoundTripper
@@ -25,6 +30,39 @@ type ApiClient struct {
 	Endpoint   string
 	HTTPClient *http.Client
 	APIKey     string
+	// Middlewares wrap every request, the first one outermost, see Use
+	Middlewares []Middleware
+
+	// dryRun records writes instead of sending them, see NewDryRunClient
+	dryRun *dryRunRecorder
+	// audit records every write, see SetAuditSink
+	audit *auditor
+	// ctx is the context of requests, see WithContext
+	ctx context.Context
+}
+
+// APIError is returned when the Admin API answers with an error status
+type APIError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("status: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound reports whether an error is an Admin API "not found" response
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// isStreamModeDisabled reports whether a stream route request failed because the gateway
+// does not run the stream proxy
+func isStreamModeDisabled(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest &&
+		strings.Contains(string(apiErr.Body), "stream mode is disabled")
 }
 
 func NewClient(endpoint, apiKey *string) (*ApiClient, error) {
@@ -37,26 +75,35 @@ func NewClient(endpoint, apiKey *string) (*ApiClient, error) {
 		return nil, fmt.Errorf("the value of the API Key is not provided")
 	}
 
-	apiClient := http.DefaultClient
-	headers := make(http.Header, 0)
-	headers.Add("X-API-KEY", *apiKey)
-
-	apiClient.Transport = AddHeadersRoundtripper{
-		Headers: headers,
-		Nested:  http.DefaultTransport,
-	}
-
 	c := ApiClient{
-		HTTPClient: apiClient,
-		Endpoint:   *endpoint,
-		APIKey:     *apiKey,
+		HTTPClient:  &http.Client{},
+		Endpoint:    *endpoint,
+		APIKey:      *apiKey,
+		Middlewares: []Middleware{APIKeyMiddleware(*apiKey)},
 	}
 
 	return &c, nil
 }
 
 func (c *ApiClient) doRequest(req *http.Request) ([]byte, error) {
-	res, err := c.HTTPClient.Do(req)
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
+	}
+
+	if c.audit != nil && isMutatingMethod(req.Method) {
+		return c.auditedRequest(req)
+	}
+
+	return c.sendRequest(req)
+}
+
+func (c *ApiClient) sendRequest(req *http.Request) ([]byte, error) {
+	op, err := operationOf(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.handler()(op, req)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +116,7 @@ func (c *ApiClient) doRequest(req *http.Request) ([]byte, error) {
 
 	// if status code >= 400
 	if res.StatusCode >= http.StatusBadRequest {
-		return nil, fmt.Errorf("status: %d, body: %s", res.StatusCode, body)
+		return nil, &APIError{StatusCode: res.StatusCode, Body: body}
 	}
 
 	return body, err
@@ -0,0 +1,329 @@
+package api_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+type AuditResult string
+
+const (
+	AuditSuccess AuditResult = "success"
+	AuditFailure AuditResult = "failure"
+)
+
+// AuditEvent describes a single write to the Admin API
+type AuditEvent struct {
+	Time   time.Time    `json:"time"`
+	Actor  string       `json:"actor,omitempty"`
+	Action SyncAction   `json:"action"`
+	Method string       `json:"method"`
+	Kind   ResourceKind `json:"kind"`
+	ID     string       `json:"id,omitempty"`
+	// SubPath addresses part of the resource, e.g. plugins/limit-count or credentials/cred-1
+	SubPath    string      `json:"sub_path,omitempty"`
+	Result     AuditResult `json:"result"`
+	StatusCode int         `json:"status_code,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	DryRun     bool        `json:"dry_run,omitempty"`
+	// Before and After are the redacted resource before and after the write, set only with
+	// AuditOptions.IncludeState
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditSink receives an event for every write of a client, after the write
+type AuditSink interface {
+	RecordAuditEvent(event AuditEvent) error
+}
+
+// AuditSinkFunc adapts a function to an AuditSink
+type AuditSinkFunc func(event AuditEvent) error
+
+func (f AuditSinkFunc) RecordAuditEvent(event AuditEvent) error {
+	return f(event)
+}
+
+type AuditOptions struct {
+	// IncludeState fetches every resource before it is written and adds both states to the
+	// event, with credentials and private keys redacted. Without it, no extra request is
+	// sent and a PUT creating a resource is recorded as an update.
+	IncludeState bool
+	// Actor is recorded when the context of the client carries none
+	Actor string
+}
+
+type auditor struct {
+	sink    AuditSink
+	options AuditOptions
+}
+
+type actorContextKey struct{}
+
+// ContextWithActor - Returns a context recording the actor of writes, see ApiClient.WithContext
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext - Returns the actor of a context, empty when it has none
+func ActorFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
+
+// WithContext - Returns a copy of the client sending its requests with a context. The
+// actor of the context is recorded in audit events.
+func (c *ApiClient) WithContext(ctx context.Context) *ApiClient {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
+}
+
+// SetAuditSink - Sends an event for every Create*, Update*, Delete* and other write of the
+// client to a sink. A nil sink stops auditing.
+//
+// The event is recorded after the write, so when the sink fails the write has usually been
+// applied already; the error of the sink is returned either way.
+func (c *ApiClient) SetAuditSink(sink AuditSink, options AuditOptions) {
+	if sink == nil {
+		c.audit = nil
+		return
+	}
+
+	c.audit = &auditor{sink: sink, options: options}
+}
+
+// auditedRequest sends a write and records its audit event
+func (c *ApiClient) auditedRequest(req *http.Request) ([]byte, error) {
+	op, err := operationOf(req)
+	if err != nil {
+		return nil, err
+	}
+	kind, id, subPath := op.Kind, op.ID, op.SubPath
+
+	event := AuditEvent{
+		Actor:   ActorFromContext(c.ctx),
+		Method:  req.Method,
+		Kind:    kind,
+		ID:      id,
+		SubPath: subPath,
+		DryRun:  c.dryRun != nil,
+	}
+	if event.Actor == "" {
+		event.Actor = c.audit.options.Actor
+	}
+
+	switch op.Verb {
+	case OperationCreate:
+		event.Action = SyncCreate
+	case OperationDelete:
+		event.Action = SyncDelete
+	default:
+		event.Action = SyncUpdate
+	}
+
+	// Only the prior state tells whether a PUT creates the resource, so without it every
+	// PUT is recorded as an update
+	if id != "" && c.audit.options.IncludeState {
+		before, err := c.getRawValue(auditTargetURL(req.URL.String(), kind, id, subPath))
+		if IsNotFound(err) && op.Verb == OperationUpdate {
+			event.Action = SyncCreate
+		}
+		if err == nil {
+			event.Before = redactedAuditState(kind, before)
+		}
+	}
+
+	body, err := c.sendRequest(req)
+	event.Time = time.Now().UTC()
+
+	if err != nil {
+		event.Result = AuditFailure
+		event.Error = err.Error()
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			event.StatusCode = apiErr.StatusCode
+		}
+	} else {
+		event.Result = AuditSuccess
+
+		response := rawResourceAPIResponse{}
+		if json.Unmarshal(body, &response) == nil {
+			if event.ID == "" && response.Key != "" {
+				event.ID = path.Base(response.Key)
+			}
+			if c.audit.options.IncludeState && req.Method != http.MethodDelete && response.Value != nil {
+				event.After = redactedAuditState(kind, response.Value)
+			}
+		}
+	}
+
+	if sinkErr := c.audit.sink.RecordAuditEvent(event); sinkErr != nil {
+		sinkErr = fmt.Errorf("failed to record audit event of %s %s: %w", req.Method, req.URL.Path, sinkErr)
+		return body, errors.Join(err, sinkErr)
+	}
+
+	return body, err
+}
+
+// auditTargetURL returns the URL of the resource a write changes. Patches of a sub-path
+// change the whole resource, credentials are resources of their own.
+func auditTargetURL(requestURL string, kind ResourceKind, id, subPath string) string {
+	base := requestURL[:strings.Index(requestURL, "/apisix/admin/")]
+	target := fmt.Sprintf("%s/apisix/admin/%s/%s", base, kind, id)
+	if strings.HasPrefix(subPath, "credentials/") {
+		target += "/" + subPath
+	}
+
+	return target
+}
+
+// getRawValue reads the value of any Admin API object as a generic map
+func (c *ApiClient) getRawValue(url string) (map[string]interface{}, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	getResponse := rawResourceAPIResponse{}
+	err = json.Unmarshal(body, &getResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return getResponse.Value, nil
+}
+
+func redactedAuditState(kind ResourceKind, value map[string]interface{}) json.RawMessage {
+	object := deepCopy(value)
+	redactRawResource(kind, object)
+
+	state, err := CanonicalJSON(object)
+	if err != nil {
+		return nil
+	}
+
+	return state
+}
+
+// JSONLinesAuditSink writes every event as a line of JSON
+type JSONLinesAuditSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewJSONLinesAuditSink - Creates a sink writing events to a writer
+func NewJSONLinesAuditSink(w io.Writer) *JSONLinesAuditSink {
+	return &JSONLinesAuditSink{w: w}
+}
+
+// OpenJSONLinesAuditFile - Creates a sink appending events to a file, creating it readable
+// by its owner only
+func OpenJSONLinesAuditFile(name string) (*JSONLinesAuditSink, error) {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONLinesAuditSink{w: file, closer: file}, nil
+}
+
+func (s *JSONLinesAuditSink) RecordAuditEvent(event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close - Closes the file of a sink opened with OpenJSONLinesAuditFile
+func (s *JSONLinesAuditSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+
+	return s.closer.Close()
+}
+
+// SlogAuditSink logs every event, failed writes at warning level
+type SlogAuditSink struct {
+	Logger *slog.Logger
+}
+
+// NewSlogAuditSink - Creates a sink logging events to a logger, the default logger when nil
+func NewSlogAuditSink(logger *slog.Logger) *SlogAuditSink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &SlogAuditSink{Logger: logger}
+}
+
+func (s *SlogAuditSink) RecordAuditEvent(event AuditEvent) error {
+	attrs := []slog.Attr{
+		slog.String("action", string(event.Action)),
+		slog.String("kind", string(event.Kind)),
+		slog.String("id", event.ID),
+		slog.String("result", string(event.Result)),
+	}
+	if event.Actor != "" {
+		attrs = append(attrs, slog.String("actor", event.Actor))
+	}
+	if event.SubPath != "" {
+		attrs = append(attrs, slog.String("sub_path", event.SubPath))
+	}
+	if event.DryRun {
+		attrs = append(attrs, slog.Bool("dry_run", true))
+	}
+	if event.StatusCode != 0 {
+		attrs = append(attrs, slog.Int("status_code", event.StatusCode))
+	}
+	if event.Error != "" {
+		attrs = append(attrs, slog.String("error", event.Error))
+	}
+	attrs = appendStateAttr(attrs, "before", event.Before)
+	attrs = appendStateAttr(attrs, "after", event.After)
+
+	level := slog.LevelInfo
+	if event.Result == AuditFailure {
+		level = slog.LevelWarn
+	}
+
+	s.Logger.LogAttrs(context.Background(), level, "admin api write", attrs...)
+	return nil
+}
+
+// appendStateAttr adds a state decoded, so handlers render it as an object
+func appendStateAttr(attrs []slog.Attr, name string, state json.RawMessage) []slog.Attr {
+	var value interface{}
+	if len(state) == 0 || json.Unmarshal(state, &value) != nil {
+		return attrs
+	}
+
+	return append(attrs, slog.Any(name, value))
+}
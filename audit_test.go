@@ -0,0 +1,103 @@
+package api_client
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// auditRecorder keeps the events a client sends to it
+type auditRecorder struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (r *auditRecorder) RecordAuditEvent(event AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestAuditEvents(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+	admin.set("consumers/jack", map[string]interface{}{
+		"username": "jack", "plugins": map[string]interface{}{"key-auth": map[string]interface{}{"key": "old-secret"}},
+	})
+	admin.fail = func(r *http.Request) bool {
+		return r.Method == http.MethodDelete && r.URL.Path == "/apisix/admin/routes/locked"
+	}
+
+	recorder := &auditRecorder{}
+	client.SetAuditSink(recorder, AuditOptions{IncludeState: true, Actor: "ci"})
+	audited := client.WithContext(ContextWithActor(context.Background(), "alice"))
+
+	uri, upstreamID := "/api/*", "backend"
+	if _, err := audited.CreateRoute(Route{URI: &uri, UpstreamId: &upstreamID}); err != nil {
+		t.Fatal(err)
+	}
+
+	username := "jack"
+	plugins := map[string]interface{}{"key-auth": map[string]interface{}{"key": "new-secret"}}
+	if _, err := client.UpdateConsumer(Consumer{Username: &username, Plugins: &plugins}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetConsumer("jack"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteRoute("locked"); err == nil {
+		t.Fatal("DeleteRoute(locked) succeeded, want the injected failure")
+	}
+
+	got := []string{}
+	for _, event := range recorder.events {
+		got = append(got, strings.Join([]string{event.Actor, string(event.Action), string(event.Kind), event.ID, string(event.Result)}, " "))
+	}
+	want := []string{
+		"alice create routes generated-1 success",
+		"ci update consumers jack success",
+		"ci delete routes locked failure",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events %v, want %v", got, want)
+	}
+
+	update := recorder.events[1]
+	if !strings.Contains(string(update.Before), `"key-auth":{"key":"[REDACTED]"}`) ||
+		!strings.Contains(string(update.After), `"key-auth":{"key":"[REDACTED]"}`) {
+		t.Errorf("update before %s, after %s, want both with the key redacted", update.Before, update.After)
+	}
+	for _, event := range recorder.events {
+		if strings.Contains(string(event.Before)+string(event.After), "secret") {
+			t.Errorf("event of %s %s leaks a key: %s / %s", event.Kind, event.ID, event.Before, event.After)
+		}
+	}
+	if created := recorder.events[0]; created.Before != nil || !strings.Contains(string(created.After), `"uri":"/api/*"`) {
+		t.Errorf("create before %s, after %s, want only the new route", created.Before, created.After)
+	}
+	if failed := recorder.events[2]; failed.StatusCode != http.StatusInternalServerError || failed.Error == "" {
+		t.Errorf("failed delete status %d, error %q, want the gateway error", failed.StatusCode, failed.Error)
+	}
+}
+
+func TestAuditWithoutState(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+
+	recorder := &auditRecorder{}
+	client.SetAuditSink(recorder, AuditOptions{})
+
+	uri, upstreamID := "/api/*", "backend"
+	if _, err := client.UpdateRoute("api", Route{URI: &uri, UpstreamId: &upstreamID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"PUT /apisix/admin/routes/api"}; !reflect.DeepEqual(admin.requests, want) {
+		t.Errorf("requests %v, want only the write", admin.requests)
+	}
+	if len(recorder.events) != 1 || recorder.events[0].Before != nil || recorder.events[0].After != nil {
+		t.Errorf("events %+v, want one without state", recorder.events)
+	}
+}
@@ -0,0 +1,374 @@
+package api_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+type BatchStepStatus string
+
+const (
+	BatchStepPending        BatchStepStatus = "pending"
+	BatchStepApplied        BatchStepStatus = "applied"
+	BatchStepFailed         BatchStepStatus = "failed"
+	BatchStepRolledBack     BatchStepStatus = "rolled back"
+	BatchStepRollbackFailed BatchStepStatus = "rollback failed"
+)
+
+// Batch collects writes to apply together. The Admin API has no transactions, so Apply
+// emulates one: it snapshots every touched object first and, when a write fails, restores
+// the snapshots and deletes the objects the batch created.
+type Batch struct {
+	client  *ApiClient
+	changes []PlannedChange
+	err     error
+}
+
+// BatchStep is the outcome of a single write of a batch. Action is create or update for
+// puts, depending on whether the object existed.
+type BatchStep struct {
+	Action SyncAction      `json:"action"`
+	Kind   ResourceKind    `json:"kind"`
+	ID     string          `json:"id"`
+	Status BatchStepStatus `json:"status"`
+	Err    error           `json:"-"`
+	// Error is the message of Err
+	Error string `json:"error,omitempty"`
+}
+
+type BatchReport struct {
+	Steps      []BatchStep `json:"steps"`
+	RolledBack bool        `json:"rolled_back"`
+}
+
+// NewBatch - Starts an empty batch of writes
+func (c *ApiClient) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// Put - Adds a create or update of an object of a kind, e.g. Put(RouteKind, "api", route)
+func (b *Batch) Put(kind ResourceKind, id string, resource interface{}) *Batch {
+	after, err := CanonicalJSON(resource)
+	if err != nil {
+		b.err = errors.Join(b.err, fmt.Errorf("failed to encode %s/%s: %w", kind, id, err))
+		return b
+	}
+
+	return b.add(PlannedChange{Action: SyncUpdate, Kind: kind, ID: id, After: after})
+}
+
+// Delete - Adds a delete of an object of a kind
+func (b *Batch) Delete(kind ResourceKind, id string) *Batch {
+	return b.add(PlannedChange{Action: SyncDelete, Kind: kind, ID: id})
+}
+
+// PutSecret - Adds a create or update of a secret, identified by its manager and ID, e.g. vault/1
+func (b *Batch) PutSecret(secretID string, fields map[string]interface{}) *Batch {
+	return b.Put(SecretKind, secretID, fields)
+}
+
+func (b *Batch) PutProto(protoID string, proto Proto) *Batch {
+	return b.Put(ProtoKind, protoID, proto)
+}
+
+func (b *Batch) PutSslCertificate(certificateID string, certificate SSLCertificate) *Batch {
+	return b.Put(SSLKind, certificateID, certificate)
+}
+
+func (b *Batch) PutUpstream(upstreamID string, upstream Upstream) *Batch {
+	return b.Put(UpstreamKind, upstreamID, upstream)
+}
+
+func (b *Batch) PutService(serviceID string, service Service) *Batch {
+	return b.Put(ServiceKind, serviceID, service)
+}
+
+func (b *Batch) PutPluginConfig(configID string, config PluginConfig) *Batch {
+	return b.Put(PluginConfigKind, configID, config)
+}
+
+func (b *Batch) PutConsumerGroup(groupID string, group ConsumerGroup) *Batch {
+	return b.Put(ConsumerGroupKind, groupID, group)
+}
+
+func (b *Batch) PutConsumer(consumer Consumer) *Batch {
+	return b.Put(ConsumerKind, stringValue(consumer.Username), consumer)
+}
+
+func (b *Batch) PutRoute(routeID string, route Route) *Batch {
+	return b.Put(RouteKind, routeID, route)
+}
+
+func (b *Batch) PutStreamRoute(routeID string, route StreamRoute) *Batch {
+	return b.Put(StreamRouteKind, routeID, route)
+}
+
+func (b *Batch) PutGlobalRule(ruleID string, rule GlobalRule) *Batch {
+	return b.Put(GlobalRuleKind, ruleID, rule)
+}
+
+func (b *Batch) PutPluginMetadata(pluginName string, metadata PluginMetadata) *Batch {
+	return b.Put(PluginMetadataKind, pluginName, metadata)
+}
+
+func (b *Batch) add(change PlannedChange) *Batch {
+	if change.ID == "" {
+		b.err = errors.Join(b.err, fmt.Errorf("%s of %s has no id", change.Action, change.Kind))
+		return b
+	}
+
+	if !isStateKind(change.Kind) {
+		b.err = errors.Join(b.err, fmt.Errorf("unsupported kind %q", change.Kind))
+		return b
+	}
+
+	for _, existing := range b.changes {
+		if existing.Kind == change.Kind && existing.ID == change.ID {
+			b.err = errors.Join(b.err, fmt.Errorf("%s/%s is written twice", change.Kind, change.ID))
+			return b
+		}
+	}
+
+	if change.Action != SyncDelete {
+		if _, err := decodeStateResource(change); err != nil {
+			b.err = errors.Join(b.err, fmt.Errorf("invalid %s/%s: %w", change.Kind, change.ID, err))
+			return b
+		}
+	}
+
+	b.changes = append(b.changes, change)
+	return b
+}
+
+// Apply - Snapshots every touched object, then writes the batch in dependency order:
+// puts from SSLs to plugin metadata, then deletes in reverse. On the first failure the
+// applied writes are undone in reverse order. Nothing is written when the batch is invalid,
+// a deleted object does not exist or an existing certificate is read without its private key.
+func (b *Batch) Apply() (*BatchReport, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	changes := orderChanges(b.changes)
+
+	report := &BatchReport{Steps: make([]BatchStep, len(changes))}
+	snapshots := make([]batchSnapshot, len(changes))
+	for i, change := range changes {
+		snapshot, err := b.client.getResource(change.Kind, change.ID)
+		if err != nil && !IsNotFound(err) {
+			return nil, fmt.Errorf("failed to snapshot %s/%s: %w", change.Kind, change.ID, err)
+		}
+
+		if change.Action == SyncDelete && snapshot == nil {
+			return nil, fmt.Errorf("cannot delete %s/%s: it does not exist", change.Kind, change.ID)
+		}
+		if change.Action != SyncDelete && snapshot == nil {
+			changes[i].Action = SyncCreate
+		}
+
+		// Gateways that do not return private keys leave nothing to restore a certificate from
+		if change.Kind == SSLKind && snapshot != nil && snapshot["key"] == nil && snapshot["keys"] == nil {
+			return nil, fmt.Errorf("cannot %s %s/%s: the gateway does not return its private key, so it could not be rolled back", change.Action, change.Kind, change.ID)
+		}
+
+		// Deleting a consumer deletes its credentials, which are objects of their own
+		var credentials []rawResourceAPIResponse
+		if change.Kind == ConsumerKind && change.Action == SyncDelete {
+			credentials, err = b.client.listRawConsumerCredentials(change.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to snapshot credentials of %s/%s: %w", change.Kind, change.ID, err)
+			}
+		}
+
+		snapshots[i] = batchSnapshot{value: snapshot, credentials: credentials}
+		report.Steps[i] = BatchStep{Action: changes[i].Action, Kind: change.Kind, ID: change.ID, Status: BatchStepPending}
+	}
+
+	for i, change := range changes {
+		err := b.client.applyChange(change)
+		if err == nil {
+			report.Steps[i].Status = BatchStepApplied
+			continue
+		}
+
+		report.Steps[i].Status = BatchStepFailed
+		report.Steps[i].setErr(err)
+		applyErr := fmt.Errorf("failed to %s %s/%s: %w", change.Action, change.Kind, change.ID, err)
+
+		rollbackErr := b.rollback(changes[:i], snapshots[:i], report)
+		report.RolledBack = true
+		return report, errors.Join(applyErr, rollbackErr)
+	}
+
+	return report, nil
+}
+
+// batchSnapshot is the state of an object before a batch wrote it
+type batchSnapshot struct {
+	value map[string]interface{}
+	// credentials are the credentials of a deleted consumer
+	credentials []rawResourceAPIResponse
+}
+
+// rollback undoes applied changes in reverse order: created objects are deleted, updated
+// and deleted ones are restored from their snapshot, including fields the types do not model
+// and the credentials of deleted consumers
+func (b *Batch) rollback(changes []PlannedChange, snapshots []batchSnapshot, report *BatchReport) error {
+	var errs []error
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+
+		var err error
+		if change.Action == SyncCreate {
+			err = b.client.deleteResource(change.Kind, change.ID)
+		} else {
+			_, err = b.client.putResource(change.Kind, change.ID, snapshots[i].value)
+			for _, credential := range snapshots[i].credentials {
+				if err != nil {
+					break
+				}
+				err = b.client.putRawConsumerCredential(change.ID, path.Base(credential.Key), credential.Value)
+			}
+		}
+
+		if err != nil {
+			report.Steps[i].Status = BatchStepRollbackFailed
+			report.Steps[i].setErr(err)
+			errs = append(errs, fmt.Errorf("failed to roll back %s/%s: %w", change.Kind, change.ID, err))
+			continue
+		}
+		report.Steps[i].Status = BatchStepRolledBack
+	}
+
+	return errors.Join(errs...)
+}
+
+// listRawConsumerCredentials returns the credentials of a consumer with their keys, keeping
+// fields the typed structs do not model. Gateways without credentials have none.
+func (c *ApiClient) listRawConsumerCredentials(consumerName string) ([]rawResourceAPIResponse, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/consumers/%s/credentials", c.Endpoint, consumerName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := rawResourceListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return listResponse.List, nil
+}
+
+// putRawConsumerCredential writes a credential read with listRawConsumerCredentials back
+func (c *ApiClient) putRawConsumerCredential(consumerName, credentialID string, value map[string]interface{}) error {
+	credential := make(map[string]interface{}, len(value))
+	for key, field := range value {
+		credential[key] = field
+	}
+	for _, field := range serverManagedFields {
+		delete(credential, field)
+	}
+
+	rb, err := json.Marshal(credential)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/apisix/admin/consumers/%s/credentials/%s", c.Endpoint, consumerName, credentialID), strings.NewReader(string(rb)))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.doRequest(req)
+	return err
+}
+
+func (s *BatchStep) setErr(err error) {
+	s.Err = err
+	s.Error = err.Error()
+}
+
+// decodeStateResource decodes the desired object of a change into its resource type
+func decodeStateResource(change PlannedChange) (interface{}, error) {
+	var resource interface{}
+	switch change.Kind {
+	case SecretKind:
+		resource = &map[string]interface{}{}
+	case SSLKind:
+		resource = &SSLCertificate{}
+	case ProtoKind:
+		resource = &Proto{}
+	case UpstreamKind:
+		resource = &Upstream{}
+	case ServiceKind:
+		resource = &Service{}
+	case PluginConfigKind:
+		resource = &PluginConfig{}
+	case ConsumerGroupKind:
+		resource = &ConsumerGroup{}
+	case ConsumerKind:
+		resource = &Consumer{}
+	case RouteKind:
+		resource = &Route{}
+	case StreamRouteKind:
+		resource = &StreamRoute{}
+	case GlobalRuleKind:
+		resource = &GlobalRule{}
+	case PluginMetadataKind:
+		resource = &PluginMetadata{}
+	default:
+		return nil, fmt.Errorf("unsupported kind %q", change.Kind)
+	}
+
+	if err := decodeChange(change, resource); err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// orderChanges sorts puts in dependency order followed by deletes in reverse dependency
+// order, keeping the order of changes to the same kind
+func orderChanges(changes []PlannedChange) []PlannedChange {
+	rank := map[ResourceKind]int{}
+	for i, kind := range stateKinds {
+		rank[kind] = i
+	}
+
+	ordered := append([]PlannedChange(nil), changes...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		iDelete, jDelete := ordered[i].Action == SyncDelete, ordered[j].Action == SyncDelete
+		if iDelete != jDelete {
+			return !iDelete
+		}
+		if iDelete {
+			return rank[ordered[i].Kind] > rank[ordered[j].Kind]
+		}
+		return rank[ordered[i].Kind] < rank[ordered[j].Kind]
+	})
+
+	return ordered
+}
+
+func isStateKind(kind ResourceKind) bool {
+	for _, stateKind := range stateKinds {
+		if stateKind == kind {
+			return true
+		}
+	}
+	return false
+}
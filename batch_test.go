@@ -0,0 +1,106 @@
+package api_client
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestOrderChanges(t *testing.T) {
+	changes := []PlannedChange{
+		{Action: SyncDelete, Kind: UpstreamKind, ID: "u-old"},
+		{Action: SyncCreate, Kind: RouteKind, ID: "r1"},
+		{Action: SyncDelete, Kind: RouteKind, ID: "r-old"},
+		{Action: SyncUpdate, Kind: UpstreamKind, ID: "u2"},
+		{Action: SyncCreate, Kind: UpstreamKind, ID: "u1"},
+		{Action: SyncCreate, Kind: PluginMetadataKind, ID: "cors"},
+		{Action: SyncDelete, Kind: SSLKind, ID: "ssl-old"},
+		{Action: SyncCreate, Kind: SecretKind, ID: "vault/1"},
+		{Action: SyncDelete, Kind: RouteKind, ID: "r-older"},
+		{Action: SyncUpdate, Kind: ServiceKind, ID: "s1"},
+	}
+
+	want := []string{
+		"create secrets vault/1",
+		"update upstreams u2",
+		"create upstreams u1",
+		"update services s1",
+		"create routes r1",
+		"create plugin_metadata cors",
+		"delete routes r-old",
+		"delete routes r-older",
+		"delete upstreams u-old",
+		"delete ssls ssl-old",
+	}
+
+	got := []string{}
+	for _, change := range orderChanges(changes) {
+		got = append(got, string(change.Action)+" "+string(change.Kind)+" "+change.ID)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("orderChanges() =\n%v\nwant\n%v", got, want)
+	}
+
+	if changes[0].ID != "u-old" {
+		t.Error("orderChanges() reordered its argument")
+	}
+}
+
+func TestBatchRollback(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+
+	originalNodes := []interface{}{map[string]interface{}{"host": "10.0.0.1", "port": 80, "weight": 1}}
+	admin.set("upstreams/backend", map[string]interface{}{"id": "backend", "type": "roundrobin", "nodes": originalNodes, "retries": 2})
+	admin.set("upstreams/legacy", map[string]interface{}{"id": "legacy", "type": "roundrobin"})
+	admin.set("consumers/jack", map[string]interface{}{"username": "jack", "desc": "jack", "plugins": map[string]interface{}{"key-auth": map[string]interface{}{"key": "jack-key"}}})
+	admin.set("consumers/jack/credentials/cred-1", map[string]interface{}{"id": "cred-1", "plugins": map[string]interface{}{"basic-auth": map[string]interface{}{"username": "jack", "password": "secret"}}})
+
+	admin.fail = func(r *http.Request) bool {
+		return r.Method == http.MethodDelete && r.URL.Path == "/apisix/admin/upstreams/legacy"
+	}
+
+	upstreamType := "roundrobin"
+	nodes := []UpstreamNodeType{{Host: "10.0.0.2", Port: 80, Weight: 1}}
+	uri := "/api/*"
+	upstreamID := "backend"
+	report, err := client.NewBatch().
+		PutUpstream("backend", Upstream{Type: &upstreamType, Nodes: &nodes}).
+		PutRoute("api", Route{URI: &uri, UpstreamId: &upstreamID}).
+		Delete(ConsumerKind, "jack").
+		Delete(UpstreamKind, "legacy").
+		Apply()
+	if err == nil {
+		t.Fatal("Apply() succeeded, want the injected failure")
+	}
+
+	wantSteps := []string{
+		"update upstreams backend: rolled back",
+		"create routes api: rolled back",
+		"delete consumers jack: rolled back",
+		"delete upstreams legacy: failed",
+	}
+	gotSteps := []string{}
+	for _, step := range report.Steps {
+		gotSteps = append(gotSteps, string(step.Action)+" "+string(step.Kind)+" "+step.ID+": "+string(step.Status))
+	}
+	if !report.RolledBack || !reflect.DeepEqual(gotSteps, wantSteps) {
+		t.Errorf("steps = %v, rolled back %v; want %v", gotSteps, report.RolledBack, wantSteps)
+	}
+
+	backend := admin.get("upstreams/backend")
+	if mustJSON(t, backend["nodes"]) != mustJSON(t, originalNodes) || mustJSON(t, backend["retries"]) != "2" {
+		t.Errorf("upstream backend = %v, want it restored", backend)
+	}
+	if admin.get("routes/api") != nil {
+		t.Error("created route api was not deleted")
+	}
+
+	jack := admin.get("consumers/jack")
+	if jack == nil || jack["desc"] != "jack" || jack["plugins"] == nil {
+		t.Errorf("consumer jack = %v, want it restored", jack)
+	}
+	credential := admin.get("consumers/jack/credentials/cred-1")
+	if credential == nil || mustJSON(t, credential["plugins"]) != `{"basic-auth":{"password":"secret","username":"jack"}}` {
+		t.Errorf("credential cred-1 = %v, want it restored", credential)
+	}
+}
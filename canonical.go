@@ -0,0 +1,280 @@
+package api_client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// setLikeFields are resource fields whose order has no meaning. Their scalar items are
+// sorted in canonical JSON. Fields inside plugin configs are never reordered.
+var setLikeFields = map[string]bool{
+	"methods":      true,
+	"hosts":        true,
+	"snis":         true,
+	"uris":         true,
+	"remote_addrs": true,
+}
+
+// CanonicalJSON - Encodes a resource as byte-stable JSON: object keys are sorted, numbers
+// are normalized (1.0 and 1e0 both become 1) and set-like fields such as methods, hosts
+// and snis are sorted. Equal resources always encode to the same bytes.
+func CanonicalJSON(value interface{}) ([]byte, error) {
+	return canonicalJSON(value, "", false)
+}
+
+// CanonicalJSONIndent - Like CanonicalJSON, with every nested element on its own line
+func CanonicalJSONIndent(value interface{}, indent string) ([]byte, error) {
+	return canonicalJSON(value, indent, false)
+}
+
+// canonicalPluginJSON encodes a plugin config or plugin metadata canonically. Their fields
+// belong to the plugin, so fields named like set-like resource fields keep their order.
+func canonicalPluginJSON(value interface{}) ([]byte, error) {
+	return canonicalJSON(value, "", true)
+}
+
+// CanonicalHash - Returns the hex SHA-256 of the canonical JSON of a resource
+func CanonicalHash(value interface{}) (string, error) {
+	encoded, err := CanonicalJSON(value)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func canonicalJSON(value interface{}, indent string, inPlugins bool) ([]byte, error) {
+	decoded, err := decodeJSONNumbers(value)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeCanonical(buf, decoded, indent, 0, inPlugins); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeJSONNumbers converts a value to its generic JSON form, keeping numbers as json.Number
+func decodeJSONNumbers(value interface{}) (interface{}, error) {
+	var encoded []byte
+	switch v := value.(type) {
+	case json.RawMessage:
+		encoded = v
+	case []byte:
+		encoded = v
+	default:
+		var err error
+		encoded, err = json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded, nil
+}
+
+func writeCanonical(buf *bytes.Buffer, value interface{}, indent string, depth int, inPlugins bool) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		number, err := canonicalNumber(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(number)
+	case string:
+		writeCanonicalString(buf, v)
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeIndent(buf, indent, depth+1)
+			if err := writeCanonical(buf, item, indent, depth+1, inPlugins); err != nil {
+				return err
+			}
+		}
+		writeIndent(buf, indent, depth)
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeIndent(buf, indent, depth+1)
+			writeCanonicalString(buf, key)
+			buf.WriteByte(':')
+			if indent != "" {
+				buf.WriteByte(' ')
+			}
+
+			item := v[key]
+			if items, ok := item.([]interface{}); ok && setLikeFields[key] && !inPlugins {
+				item = sortedScalars(items)
+			}
+
+			if err := writeCanonical(buf, item, indent, depth+1, inPlugins || key == "plugins"); err != nil {
+				return err
+			}
+		}
+		writeIndent(buf, indent, depth)
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unsupported JSON value of type %T", value)
+	}
+
+	return nil
+}
+
+func writeIndent(buf *bytes.Buffer, indent string, depth int) {
+	if indent == "" {
+		return
+	}
+
+	buf.WriteByte('\n')
+	buf.WriteString(strings.Repeat(indent, depth))
+}
+
+func writeCanonicalString(buf *bytes.Buffer, value string) {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	// Encode always terminates with a newline
+	buf.Truncate(buf.Len() - 1)
+}
+
+// maxCanonicalIntegerDigits bounds the integers canonicalNumber expands from an exponent
+const maxCanonicalIntegerDigits = 400
+
+// canonicalNumber writes integral numbers as plain integers, whether written with a
+// fraction or an exponent or not, and other numbers in their shortest round-trip form.
+// Equal numbers always give the same bytes: 1e20, 1.0e20 and 100000000000000000000 all
+// become 100000000000000000000.
+func canonicalNumber(number json.Number) (string, error) {
+	literal := number.String()
+
+	sign, digits, exponent, err := decimalParts(literal)
+	if err != nil {
+		return "", fmt.Errorf("invalid number %s: %w", literal, err)
+	}
+	if digits == "" {
+		return "0", nil
+	}
+
+	if exponent >= 0 {
+		if len(digits)+exponent > maxCanonicalIntegerDigits {
+			return "", fmt.Errorf("invalid number %s: too large", literal)
+		}
+		return sign + digits + strings.Repeat("0", exponent), nil
+	}
+
+	float, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid number %s: %w", literal, err)
+	}
+
+	return strconv.FormatFloat(float, 'g', -1, 64), nil
+}
+
+// decimalParts splits a JSON number into its sign and the digits and exponent of its exact
+// decimal value, without leading or trailing zeros. Zero has no digits.
+func decimalParts(literal string) (sign string, digits string, exponent int, err error) {
+	mantissa := literal
+	if strings.HasPrefix(mantissa, "-") {
+		sign, mantissa = "-", mantissa[1:]
+	}
+
+	if i := strings.IndexAny(mantissa, "eE"); i >= 0 {
+		exponent, err = strconv.Atoi(strings.TrimPrefix(mantissa[i+1:], "+"))
+		if err != nil {
+			return "", "", 0, err
+		}
+		mantissa = mantissa[:i]
+	}
+
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+	digits = integer + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return "", "", 0, errors.New("not a decimal number")
+	}
+	exponent -= len(fraction)
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "", "", 0, nil
+	}
+
+	trimmed := strings.TrimRight(digits, "0")
+	exponent += len(digits) - len(trimmed)
+
+	return sign, trimmed, exponent, nil
+}
+
+// sortedScalars sorts a set-like array when all of its items are scalars
+func sortedScalars(items []interface{}) []interface{} {
+	type sortItem struct {
+		key   string
+		value interface{}
+	}
+
+	sortItems := make([]sortItem, len(items))
+	for i, item := range items {
+		switch item.(type) {
+		case string, json.Number, bool:
+			sortItems[i] = sortItem{key: fmt.Sprintf("%T:%v", item, item), value: item}
+		default:
+			return items
+		}
+	}
+
+	sort.SliceStable(sortItems, func(i, j int) bool {
+		return sortItems[i].key < sortItems[j].key
+	})
+
+	sorted := make([]interface{}, len(items))
+	for i, item := range sortItems {
+		sorted[i] = item.value
+	}
+
+	return sorted
+}
@@ -0,0 +1,105 @@
+package api_client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "sorted keys",
+			value: `{"b":1,"a":{"d":true,"c":null}}`,
+			want:  `{"a":{"c":null,"d":true},"b":1}`,
+		},
+		{
+			name:  "set-like fields sorted",
+			value: `{"methods":["POST","GET"],"hosts":["b.com","a.com"],"uris":["/b","/a"]}`,
+			want:  `{"hosts":["a.com","b.com"],"methods":["GET","POST"],"uris":["/a","/b"]}`,
+		},
+		{
+			name:  "ordered arrays kept",
+			value: `{"nodes":["b","a"]}`,
+			want:  `{"nodes":["b","a"]}`,
+		},
+		{
+			name:  "plugin configs kept",
+			value: `{"plugins":{"cors":{"methods":["POST","GET"]}}}`,
+			want:  `{"plugins":{"cors":{"methods":["POST","GET"]}}}`,
+		},
+		{
+			name:  "html not escaped",
+			value: `{"uri":"/a?b=<c>&d"}`,
+			want:  `{"uri":"/a?b=<c>&d"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := CanonicalJSON(json.RawMessage(test.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("CanonicalJSON() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalNumber(t *testing.T) {
+	tests := []struct {
+		literals []string
+		want     string
+	}{
+		{literals: []string{"0", "-0", "0.0", "0e10", "-0.0e-3"}, want: "0"},
+		{literals: []string{"1", "1.0", "1e0", "1E+0", "10e-1", "0.1e1", "1.000"}, want: "1"},
+		{literals: []string{"-42", "-42.0", "-4.2e1", "-420e-1"}, want: "-42"},
+		{literals: []string{"100000000000000000000", "1e20", "1.0e20", "10e19", "100000000000000000000.000"}, want: "100000000000000000000"},
+		{literals: []string{"9007199254740993", "9007199254740993.0", "9.007199254740993e15"}, want: "9007199254740993"},
+		{literals: []string{"0.5", "5e-1", "0.50", "50E-2"}, want: "0.5"},
+		{literals: []string{"1.25e-7", "0.000000125"}, want: "1.25e-07"},
+	}
+
+	for _, test := range tests {
+		for _, literal := range test.literals {
+			got, err := canonicalNumber(json.Number(literal))
+			if err != nil {
+				t.Errorf("canonicalNumber(%s) failed: %v", literal, err)
+				continue
+			}
+			if got != test.want {
+				t.Errorf("canonicalNumber(%s) = %s, want %s", literal, got, test.want)
+			}
+		}
+	}
+
+	for _, literal := range []string{"1e1000", "abc", "1.2.3"} {
+		if got, err := canonicalNumber(json.Number(literal)); err == nil {
+			t.Errorf("canonicalNumber(%s) = %s, want an error", literal, got)
+		}
+	}
+}
+
+func TestPluginMetadataMarshalJSON(t *testing.T) {
+	id := "cors"
+	metadata := map[string]interface{}{
+		"methods": []interface{}{"POST", "GET"},
+		"hosts":   []interface{}{"b.com", "a.com"},
+		"timeout": 3.0,
+	}
+
+	got, err := json.Marshal(PluginMetadata{Id: &id, Metadata: &metadata})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"hosts":["b.com","a.com"],"id":"cors","methods":["POST","GET"],"timeout":3}`
+	if string(got) != want {
+		t.Errorf("MarshalJSON() = %s, want %s", got, want)
+	}
+}
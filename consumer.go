@@ -21,6 +21,11 @@ type ConsumerAPIResponse struct {
 	Value Consumer `json:"value"`
 }
 
+type ConsumerListAPIResponse struct {
+	Total int                   `json:"total"`
+	List  []ConsumerAPIResponse `json:"list"`
+}
+
 // GetConsumer - Returns a specific consumer
 func (c *ApiClient) GetConsumer(consumerName string) (*Consumer, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/consumers/%s", c.Endpoint, consumerName), nil)
@@ -42,6 +47,32 @@ func (c *ApiClient) GetConsumer(consumerName string) (*Consumer, error) {
 	return &getResponse.Value, nil
 }
 
+// ListConsumers - Returns all consumers
+func (c *ApiClient) ListConsumers() ([]Consumer, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/consumers", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := ConsumerListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	consumers := make([]Consumer, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		consumers = append(consumers, item.Value)
+	}
+
+	return consumers, nil
+}
+
 // CreateConsumer - Creates a consumer
 func (c *ApiClient) CreateConsumer(consumer Consumer) (*Consumer, error) {
 	rb, err := json.Marshal(consumer)
@@ -0,0 +1,129 @@
+package api_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type ConsumerCredential struct {
+	ID          *string                 `json:"id,omitempty"`
+	Description *string                 `json:"desc,omitempty"`
+	Labels      *map[string]string      `json:"labels,omitempty"`
+	Plugins     *map[string]interface{} `json:"plugins,omitempty"`
+}
+
+type ConsumerCredentialAPIResponse struct {
+	Key   string             `json:"key"`
+	Value ConsumerCredential `json:"value"`
+}
+
+type ConsumerCredentialListAPIResponse struct {
+	Total int                             `json:"total"`
+	List  []ConsumerCredentialAPIResponse `json:"list"`
+}
+
+// GetConsumerCredential - Returns a specific credential of a consumer
+func (c *ApiClient) GetConsumerCredential(consumerName, credentialID string) (*ConsumerCredential, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/consumers/%s/credentials/%s", c.Endpoint, consumerName, credentialID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	getResponse := ConsumerCredentialAPIResponse{}
+	err = json.Unmarshal(body, &getResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &getResponse.Value, nil
+}
+
+// ListConsumerCredentials - Returns all credentials of a consumer
+func (c *ApiClient) ListConsumerCredentials(consumerName string) ([]ConsumerCredential, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/consumers/%s/credentials", c.Endpoint, consumerName), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := ConsumerCredentialListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]ConsumerCredential, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		credentials = append(credentials, item.Value)
+	}
+
+	return credentials, nil
+}
+
+// CreateConsumerCredential - Creates a credential of a consumer
+func (c *ApiClient) CreateConsumerCredential(consumerName, credentialID string, credential ConsumerCredential) (*ConsumerCredential, error) {
+	rb, err := json.Marshal(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/apisix/admin/consumers/%s/credentials/%s", c.Endpoint, consumerName, credentialID), strings.NewReader(string(rb)))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	creationResponse := ConsumerCredentialAPIResponse{}
+	err = json.Unmarshal(body, &creationResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &creationResponse.Value, nil
+}
+
+// UpdateConsumerCredential - Updates a credential of a consumer
+func (c *ApiClient) UpdateConsumerCredential(consumerName, credentialID string, credential ConsumerCredential) (*ConsumerCredential, error) {
+	return c.CreateConsumerCredential(consumerName, credentialID, credential)
+}
+
+// DeleteConsumerCredential - Deletes a credential of a consumer
+func (c *ApiClient) DeleteConsumerCredential(consumerName, credentialID string) error {
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/apisix/admin/consumers/%s/credentials/%s", c.Endpoint, consumerName, credentialID), nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return err
+	}
+
+	deleteResponse := DeleteResponse{}
+	err = json.Unmarshal(body, &deleteResponse)
+	if err != nil {
+		return err
+	}
+
+	if deleteResponse.Deleted != "1" {
+		return errors.New(string(body))
+	}
+
+	return nil
+}
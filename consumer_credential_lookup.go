@@ -0,0 +1,119 @@
+package api_client
+
+import (
+	"fmt"
+	"sort"
+)
+
+// credentialIdentifierFields are the auth plugin fields that identify a credential
+var credentialIdentifierFields = map[string][]string{
+	KeyAuthPlugin:   {"key"},
+	JWTAuthPlugin:   {"key"},
+	BasicAuthPlugin: {"username"},
+	HMACAuthPlugin:  {"key_id", "access_key"},
+}
+
+// CredentialMatch is an auth plugin credential matching a looked up value. CredentialID
+// is empty when the credential is configured in the plugins of the consumer itself.
+type CredentialMatch struct {
+	Consumer     Consumer
+	CredentialID string
+	Plugin       string
+	Field        string
+	Value        string
+}
+
+// FindCredentialOwner - Searches the auth plugins of all consumers and their credentials
+// for a key-auth key, jwt-auth key, basic-auth username or hmac-auth key.
+//
+// Values the gateway stores encrypted or as $secret:// references cannot be matched.
+func (c *ApiClient) FindCredentialOwner(value string) ([]CredentialMatch, error) {
+	consumers, err := c.ListConsumers()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []CredentialMatch
+	for _, consumer := range consumers {
+		for _, match := range matchCredential(consumer.Plugins, value) {
+			match.Consumer = consumer
+			matches = append(matches, match)
+		}
+
+		// Gateways older than 3.10 have no credentials sub-resource
+		credentials, err := c.ListConsumerCredentials(stringValue(consumer.Username))
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list credentials of consumer %s: %w", stringValue(consumer.Username), err)
+		}
+
+		for _, credential := range credentials {
+			for _, match := range matchCredential(credential.Plugins, value) {
+				match.Consumer = consumer
+				match.CredentialID = stringValue(credential.ID)
+				matches = append(matches, match)
+			}
+		}
+	}
+
+	return matches, nil
+}
+
+// RevokeCredential - Removes just the matched credential: the credential resource when it
+// came from the credentials sub-resource, otherwise the auth plugin of the consumer
+func (c *ApiClient) RevokeCredential(match CredentialMatch) error {
+	username := stringValue(match.Consumer.Username)
+
+	if match.CredentialID != "" {
+		return c.DeleteConsumerCredential(username, match.CredentialID)
+	}
+
+	consumer, err := c.getResource(ConsumerKind, username)
+	if err != nil {
+		return fmt.Errorf("failed to get consumer %s: %w", username, err)
+	}
+
+	plugins, _ := consumer["plugins"].(map[string]interface{})
+	conf, _ := plugins[match.Plugin].(map[string]interface{})
+	if current, _ := conf[match.Field].(string); current != match.Value {
+		return fmt.Errorf("consumer %s no longer has the matched %s credential", username, match.Plugin)
+	}
+
+	delete(plugins, match.Plugin)
+
+	if _, err := c.putResource(ConsumerKind, username, consumer); err != nil {
+		return fmt.Errorf("failed to update consumer %s: %w", username, err)
+	}
+
+	return nil
+}
+
+func matchCredential(plugins *map[string]interface{}, value string) []CredentialMatch {
+	if plugins == nil || value == "" {
+		return nil
+	}
+
+	names := make([]string, 0, len(credentialIdentifierFields))
+	for plugin := range credentialIdentifierFields {
+		names = append(names, plugin)
+	}
+	sort.Strings(names)
+
+	var matches []CredentialMatch
+	for _, plugin := range names {
+		conf, ok := (*plugins)[plugin].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, field := range credentialIdentifierFields[plugin] {
+			if current, ok := conf[field].(string); ok && current == value {
+				matches = append(matches, CredentialMatch{Plugin: plugin, Field: field, Value: value})
+			}
+		}
+	}
+
+	return matches
+}
@@ -0,0 +1,215 @@
+package api_client
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+const (
+	KeyAuthPlugin   = "key-auth"
+	JWTAuthPlugin   = "jwt-auth"
+	BasicAuthPlugin = "basic-auth"
+	HMACAuthPlugin  = "hmac-auth"
+)
+
+// CredentialOptions control how generated credentials are stored on the consumer
+type CredentialOptions struct {
+	// SecretStore, when set, makes the plugin config reference the sensitive values
+	// as $secret://manager/id/key/<field> instead of holding them inline. The caller
+	// must write the returned plaintext values to the secret manager under these keys.
+	SecretStore *SecretReference
+}
+
+// GeneratedCredential is a freshly generated auth plugin credential. Values holds the
+// plaintext and is the only place it is available, so hand it off right away.
+type GeneratedCredential struct {
+	Plugin string
+	Values map[string]string
+	// References maps the fields stored as $secret:// references to the reference
+	References map[string]string
+	// Config is the plugin config to set on the consumer
+	Config map[string]interface{}
+}
+
+// NewKeyAuthCredential - Generates a random key-auth key
+func NewKeyAuthCredential(options CredentialOptions) (*GeneratedCredential, error) {
+	key, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := newGeneratedCredential(KeyAuthPlugin)
+	credential.setSensitive("key", key, options)
+	return credential, nil
+}
+
+// NewJWTAuthHS256Credential - Generates a jwt-auth credential signed with a random HS256 secret
+func NewJWTAuthHS256Credential(key string, options CredentialOptions) (*GeneratedCredential, error) {
+	if key == "" {
+		return nil, errors.New("jwt-auth key is required")
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := newGeneratedCredential(JWTAuthPlugin)
+	credential.set("key", key)
+	credential.set("algorithm", "HS256")
+	credential.setSensitive("secret", secret, options)
+	return credential, nil
+}
+
+// NewJWTAuthRS256Credential - Generates a jwt-auth credential with a new RSA key pair.
+// Only the public key is configured on the gateway, the private key is returned in
+// Values for whoever signs the tokens. Nothing sensitive is stored on the gateway, so
+// unlike the other generators it takes no CredentialOptions.
+func NewJWTAuthRS256Credential(key string) (*GeneratedCredential, error) {
+	if key == "" {
+		return nil, errors.New("jwt-auth key is required")
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := newGeneratedCredential(JWTAuthPlugin)
+	credential.set("key", key)
+	credential.set("algorithm", "RS256")
+	credential.set("public_key", string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})))
+	credential.Values["private_key"] = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	return credential, nil
+}
+
+// NewBasicAuthCredential - Generates a random basic-auth password for a username
+func NewBasicAuthCredential(username string, options CredentialOptions) (*GeneratedCredential, error) {
+	if username == "" {
+		return nil, errors.New("basic-auth username is required")
+	}
+
+	password, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := newGeneratedCredential(BasicAuthPlugin)
+	credential.set("username", username)
+	credential.setSensitive("password", password, options)
+	return credential, nil
+}
+
+// NewHMACAuthCredential - Generates an hmac-auth key ID and secret key pair
+func NewHMACAuthCredential(options CredentialOptions) (*GeneratedCredential, error) {
+	keyID := make([]byte, 16)
+	if _, err := rand.Read(keyID); err != nil {
+		return nil, err
+	}
+
+	secretKey, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	credential := newGeneratedCredential(HMACAuthPlugin)
+	credential.set("key_id", hex.EncodeToString(keyID))
+	credential.setSensitive("secret_key", secretKey, options)
+	return credential, nil
+}
+
+// CreateConsumerWithCredential - Creates a consumer with a generated credential added to its plugins
+func (c *ApiClient) CreateConsumerWithCredential(consumer Consumer, credential *GeneratedCredential) (*Consumer, error) {
+	plugins := map[string]interface{}{}
+	if consumer.Plugins != nil {
+		for name, conf := range *consumer.Plugins {
+			plugins[name] = conf
+		}
+	}
+
+	if _, exists := plugins[credential.Plugin]; exists {
+		return nil, fmt.Errorf("consumer already has a %s config", credential.Plugin)
+	}
+
+	plugins[credential.Plugin] = credential.Config
+	consumer.Plugins = &plugins
+
+	return c.CreateConsumer(consumer)
+}
+
+func (g GeneratedCredential) String() string {
+	fields := make([]string, 0, len(g.Values))
+	for field := range g.Values {
+		fields = append(fields, field+"="+RedactedValue)
+	}
+	sort.Strings(fields)
+
+	return fmt.Sprintf("%s{%s}", g.Plugin, strings.Join(fields, " "))
+}
+
+func (g GeneratedCredential) Format(f fmt.State, verb rune) { formatRedacted(f, verb, g.String()) }
+
+func (g GeneratedCredential) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("plugin", g.Plugin), slog.String("values", RedactedValue))
+}
+
+func newGeneratedCredential(plugin string) *GeneratedCredential {
+	return &GeneratedCredential{
+		Plugin:     plugin,
+		Values:     map[string]string{},
+		References: map[string]string{},
+		Config:     map[string]interface{}{},
+	}
+}
+
+func (g *GeneratedCredential) set(field, value string) {
+	g.Values[field] = value
+	g.Config[field] = value
+}
+
+func (g *GeneratedCredential) setSensitive(field, value string, options CredentialOptions) {
+	g.Values[field] = value
+
+	if options.SecretStore == nil {
+		g.Config[field] = value
+		return
+	}
+
+	store := options.SecretStore
+	key := field
+	if prefix := strings.Trim(store.Key, "/"); prefix != "" {
+		key = prefix + "/" + field
+	}
+
+	reference := store.Manager.Reference(store.ID, key).String()
+	g.References[field] = reference
+	g.Config[field] = reference
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,245 @@
+package api_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// ConsumerExpiryLabel holds the RFC 3339 expiry time of a consumer, e.g. 2026-10-19T00:00:00Z.
+// Consumers without the label may instead carry "[expires:<RFC 3339 time>]" in their desc.
+const ConsumerExpiryLabel = "expires_at"
+
+var consumerExpiryDescPattern = regexp.MustCompile(`\[expires:(\S+?)\]`)
+
+// consumerAuthPlugins are stripped from a consumer to disable it
+var consumerAuthPlugins = []string{KeyAuthPlugin, JWTAuthPlugin, BasicAuthPlugin, HMACAuthPlugin, "ldap-auth", "wolf-rbac"}
+
+type ConsumerReaperAction string
+
+const (
+	// ConsumerReaperDisable strips the auth plugins and credentials of expired consumers
+	ConsumerReaperDisable ConsumerReaperAction = "disable"
+	// ConsumerReaperDelete deletes expired consumers
+	ConsumerReaperDelete ConsumerReaperAction = "delete"
+)
+
+type ConsumerReaperOptions struct {
+	Action ConsumerReaperAction
+	DryRun bool
+	// Now returns the current time, time.Now when nil
+	Now func() time.Time
+}
+
+type ConsumerReaperResult struct {
+	Username  string
+	ExpiresAt time.Time
+	// Outcome is "disabled", "deleted", "already disabled" or "failed"
+	Outcome string
+	Err     error
+}
+
+type ConsumerReaperReport struct {
+	Checked int
+	DryRun  bool
+	Results []ConsumerReaperResult
+}
+
+// ConsumerExpiry - Returns the expiry time of a consumer and whether it has one
+func ConsumerExpiry(consumer Consumer) (time.Time, bool, error) {
+	value := ""
+	if consumer.Labels != nil {
+		value = (*consumer.Labels)[ConsumerExpiryLabel]
+	}
+
+	if value == "" && consumer.Description != nil {
+		if match := consumerExpiryDescPattern.FindStringSubmatch(*consumer.Description); match != nil {
+			value = match[1]
+		}
+	}
+
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("invalid expiry %q of consumer %s: %w", value, stringValue(consumer.Username), err)
+	}
+
+	return expiresAt, true, nil
+}
+
+// SetConsumerExpiry - Sets the expiry label of a consumer, keeping its other fields
+func (c *ApiClient) SetConsumerExpiry(username string, expiresAt time.Time) error {
+	consumer, err := c.getResource(ConsumerKind, username)
+	if err != nil {
+		return err
+	}
+
+	labels, _ := consumer["labels"].(map[string]interface{})
+	if labels == nil {
+		labels = map[string]interface{}{}
+	}
+	labels[ConsumerExpiryLabel] = expiresAt.UTC().Format(time.RFC3339)
+	consumer["labels"] = labels
+
+	_, err = c.putResource(ConsumerKind, username, consumer)
+	return err
+}
+
+// ClearConsumerExpiry - Removes the expiry label of a consumer
+func (c *ApiClient) ClearConsumerExpiry(username string) error {
+	consumer, err := c.getResource(ConsumerKind, username)
+	if err != nil {
+		return err
+	}
+
+	labels, _ := consumer["labels"].(map[string]interface{})
+	if _, exists := labels[ConsumerExpiryLabel]; !exists {
+		return nil
+	}
+
+	delete(labels, ConsumerExpiryLabel)
+	if len(labels) == 0 {
+		delete(consumer, "labels")
+	}
+
+	_, err = c.putResource(ConsumerKind, username, consumer)
+	return err
+}
+
+// ReapExpiredConsumers - Disables or deletes every consumer whose expiry has passed
+func (c *ApiClient) ReapExpiredConsumers(options ConsumerReaperOptions) (*ConsumerReaperReport, error) {
+	if options.Action != ConsumerReaperDisable && options.Action != ConsumerReaperDelete {
+		return nil, fmt.Errorf("unsupported reaper action: %q", options.Action)
+	}
+
+	now := time.Now()
+	if options.Now != nil {
+		now = options.Now()
+	}
+
+	consumers, err := c.ListConsumers()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(consumers, func(i, j int) bool {
+		return stringValue(consumers[i].Username) < stringValue(consumers[j].Username)
+	})
+
+	report := &ConsumerReaperReport{Checked: len(consumers), DryRun: options.DryRun}
+	for _, consumer := range consumers {
+		username := stringValue(consumer.Username)
+
+		expiresAt, hasExpiry, err := ConsumerExpiry(consumer)
+		if err != nil {
+			report.Results = append(report.Results, ConsumerReaperResult{Username: username, Outcome: "failed", Err: err})
+			continue
+		}
+
+		if !hasExpiry || now.Before(expiresAt) {
+			continue
+		}
+
+		result := ConsumerReaperResult{Username: username, ExpiresAt: expiresAt}
+		switch options.Action {
+		case ConsumerReaperDelete:
+			result.Outcome = "deleted"
+			if !options.DryRun {
+				err = c.DeleteConsumer(username)
+			}
+		case ConsumerReaperDisable:
+			var disabled bool
+			disabled, err = c.disableConsumer(username, options.DryRun)
+			result.Outcome = "disabled"
+			if err == nil && !disabled {
+				result.Outcome = "already disabled"
+			}
+		}
+
+		if err != nil {
+			result.Outcome, result.Err = "failed", err
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	return report, nil
+}
+
+// RunConsumerReaper - Reaps expired consumers every interval until the context is done.
+// Every run is passed to onReport, which may be nil.
+func (c *ApiClient) RunConsumerReaper(ctx context.Context, interval time.Duration, options ConsumerReaperOptions, onReport func(*ConsumerReaperReport, error)) error {
+	if interval <= 0 {
+		return errors.New("reaper interval must be positive")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Requests of a pass are cancelled with the context too
+	reaper := c.WithContext(ctx)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		report, err := reaper.ReapExpiredConsumers(options)
+		if onReport != nil {
+			onReport(report, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// disableConsumer strips the auth plugins and credentials of a consumer and reports
+// whether there was anything to remove
+func (c *ApiClient) disableConsumer(username string, dryRun bool) (bool, error) {
+	consumer, err := c.getResource(ConsumerKind, username)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	plugins, _ := consumer["plugins"].(map[string]interface{})
+	for _, plugin := range consumerAuthPlugins {
+		if _, exists := plugins[plugin]; exists {
+			delete(plugins, plugin)
+			changed = true
+		}
+	}
+
+	if changed && !dryRun {
+		if _, err := c.putResource(ConsumerKind, username, consumer); err != nil {
+			return false, err
+		}
+	}
+
+	// Gateways older than 3.10 have no credentials sub-resource
+	credentials, err := c.ListConsumerCredentials(username)
+	if err != nil && !IsNotFound(err) {
+		return changed, err
+	}
+
+	for _, credential := range credentials {
+		changed = true
+		if dryRun {
+			continue
+		}
+
+		if err := c.DeleteConsumerCredential(username, stringValue(credential.ID)); err != nil {
+			return changed, err
+		}
+	}
+
+	return changed, nil
+}
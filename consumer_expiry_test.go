@@ -0,0 +1,37 @@
+package api_client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunConsumerReaperStops(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.RunConsumerReaper(ctx, time.Hour, ConsumerReaperOptions{Action: ConsumerReaperDisable}, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("RunConsumerReaper() with a done context error = %v, want context.Canceled", err)
+	}
+	if len(admin.requests) != 0 {
+		t.Errorf("requests %v, want none after cancellation", admin.requests)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	passes := 0
+	err := client.RunConsumerReaper(ctx, time.Millisecond, ConsumerReaperOptions{Action: ConsumerReaperDisable}, func(report *ConsumerReaperReport, err error) {
+		if err != nil {
+			t.Errorf("pass error = %v", err)
+		}
+		passes++
+		if passes == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) || passes != 2 {
+		t.Errorf("RunConsumerReaper() = %v after %d passes, want context.Canceled after 2", err, passes)
+	}
+}
@@ -20,6 +20,11 @@ type ConsumerGroupAPIResponse struct {
 	Value ConsumerGroup `json:"value"`
 }
 
+type ConsumerGroupListAPIResponse struct {
+	Total int                        `json:"total"`
+	List  []ConsumerGroupAPIResponse `json:"list"`
+}
+
 // GetConsumerGroup - Returns a consumer group
 func (c *ApiClient) GetConsumerGroup(groupID string) (*ConsumerGroup, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/consumer_groups/%s", c.Endpoint, groupID), nil)
@@ -41,6 +46,32 @@ func (c *ApiClient) GetConsumerGroup(groupID string) (*ConsumerGroup, error) {
 	return &getResponse.Value, nil
 }
 
+// ListConsumerGroups - Returns all consumer groups
+func (c *ApiClient) ListConsumerGroups() ([]ConsumerGroup, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/consumer_groups", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := ConsumerGroupListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]ConsumerGroup, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		groups = append(groups, item.Value)
+	}
+
+	return groups, nil
+}
+
 // CreateConsumerGroup - Creates a new consumer group
 func (c *ApiClient) CreateConsumerGroup(groupID string, group ConsumerGroup) (*ConsumerGroup, error) {
 	rb, err := json.Marshal(group)
@@ -93,8 +124,27 @@ func (c *ApiClient) UpdateConsumerGroup(groupID string, group ConsumerGroup) (*C
 	return &updateResponse.Value, nil
 }
 
-// DeleteConsumerGroup - Deletes a consumer group
+// DeleteConsumerGroup - Deletes a consumer group. Groups that still have members are
+// not deleted and a ConsumerGroupInUseError is returned.
 func (c *ApiClient) DeleteConsumerGroup(groupID string) error {
+	members, err := c.ListConsumerGroupMembers(groupID)
+	if err != nil {
+		return err
+	}
+
+	if len(members) > 0 {
+		usernames := make([]string, 0, len(members))
+		for _, member := range members {
+			usernames = append(usernames, stringValue(member.Username))
+		}
+		return &ConsumerGroupInUseError{GroupID: groupID, Members: usernames}
+	}
+
+	return c.ForceDeleteConsumerGroup(groupID)
+}
+
+// ForceDeleteConsumerGroup - Deletes a consumer group without checking for members
+func (c *ApiClient) ForceDeleteConsumerGroup(groupID string) error {
 	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/apisix/admin/consumer_groups/%s", c.Endpoint, groupID), nil)
 	if err != nil {
 		return err
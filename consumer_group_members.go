@@ -0,0 +1,122 @@
+package api_client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConsumerGroupInUseError is returned when deleting a consumer group that still has members
+type ConsumerGroupInUseError struct {
+	GroupID string
+	Members []string
+}
+
+func (e *ConsumerGroupInUseError) Error() string {
+	return fmt.Sprintf("consumer group %s still has %d member(s): %s", e.GroupID, len(e.Members), strings.Join(e.Members, ", "))
+}
+
+// ListConsumerGroupMembers - Returns the consumers assigned to a consumer group
+func (c *ApiClient) ListConsumerGroupMembers(groupID string) ([]Consumer, error) {
+	consumers, err := c.ListConsumers()
+	if err != nil {
+		return nil, err
+	}
+
+	var members []Consumer
+	for _, consumer := range consumers {
+		if consumer.GroupId != nil && *consumer.GroupId == groupID {
+			members = append(members, consumer)
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return stringValue(members[i].Username) < stringValue(members[j].Username)
+	})
+
+	return members, nil
+}
+
+// AddConsumersToGroup - Assigns consumers to a consumer group, moving them out of any other group
+func (c *ApiClient) AddConsumersToGroup(groupID string, usernames ...string) error {
+	if _, err := c.GetConsumerGroup(groupID); err != nil {
+		return fmt.Errorf("failed to get consumer group %s: %w", groupID, err)
+	}
+
+	for _, username := range usernames {
+		if err := c.setConsumerGroup(username, &groupID, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveConsumersFromGroup - Removes consumers from a consumer group. Consumers that are
+// not members of the group are left alone.
+func (c *ApiClient) RemoveConsumersFromGroup(groupID string, usernames ...string) error {
+	for _, username := range usernames {
+		if err := c.setConsumerGroup(username, nil, &groupID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MoveConsumers - Moves every member of a consumer group to another group and returns
+// the usernames of the moved consumers
+func (c *ApiClient) MoveConsumers(fromGroupID, toGroupID string) ([]string, error) {
+	if _, err := c.GetConsumerGroup(toGroupID); err != nil {
+		return nil, fmt.Errorf("failed to get consumer group %s: %w", toGroupID, err)
+	}
+
+	members, err := c.ListConsumerGroupMembers(fromGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	var moved []string
+	for _, member := range members {
+		username := stringValue(member.Username)
+		if err := c.setConsumerGroup(username, &toGroupID, &fromGroupID); err != nil {
+			return moved, err
+		}
+		moved = append(moved, username)
+	}
+
+	return moved, nil
+}
+
+// setConsumerGroup changes the group of a consumer, or clears it when groupID is nil.
+// When onlyFrom is set, consumers in other groups are skipped. The consumer is read
+// and written back as is, so fields not modelled by Consumer are kept.
+func (c *ApiClient) setConsumerGroup(username string, groupID, onlyFrom *string) error {
+	consumer, err := c.getResource(ConsumerKind, username)
+	if err != nil {
+		return fmt.Errorf("failed to get consumer %s: %w", username, err)
+	}
+
+	current, _ := consumer["group_id"].(string)
+	if onlyFrom != nil && current != *onlyFrom {
+		return nil
+	}
+
+	if groupID == nil {
+		if _, exists := consumer["group_id"]; !exists {
+			return nil
+		}
+		delete(consumer, "group_id")
+	} else {
+		if current == *groupID {
+			return nil
+		}
+		consumer["group_id"] = *groupID
+	}
+
+	if _, err := c.putResource(ConsumerKind, username, consumer); err != nil {
+		return fmt.Errorf("failed to update consumer %s: %w", username, err)
+	}
+
+	return nil
+}
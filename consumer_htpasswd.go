@@ -0,0 +1,181 @@
+package api_client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HtpasswdFormat is the password storage format of an htpasswd entry
+type HtpasswdFormat string
+
+const (
+	HtpasswdPlain       HtpasswdFormat = "plain"
+	HtpasswdBcrypt      HtpasswdFormat = "bcrypt"
+	HtpasswdAPR1        HtpasswdFormat = "apr1"
+	HtpasswdSHA1        HtpasswdFormat = "sha1"
+	HtpasswdMD5Crypt    HtpasswdFormat = "md5-crypt"
+	HtpasswdSHA256Crypt HtpasswdFormat = "sha256-crypt"
+	HtpasswdSHA512Crypt HtpasswdFormat = "sha512-crypt"
+	HtpasswdDESCrypt    HtpasswdFormat = "des-crypt"
+)
+
+var (
+	desCryptPattern         = regexp.MustCompile(`^[./0-9A-Za-z]{13}$`)
+	invalidConsumerUsername = regexp.MustCompile(`[^a-zA-Z0-9_\-]`)
+)
+
+type HtpasswdEntry struct {
+	Line     int
+	Username string
+	Password string
+	Format   HtpasswdFormat
+}
+
+type HtpasswdImportOptions struct {
+	// GroupID assigns the imported consumers to a consumer group
+	GroupID *string
+	// Labels are added to the imported consumers
+	Labels map[string]string
+	// AssumePlaintext imports 13 character passwords that look like a crypt(3) DES hash as plaintext
+	AssumePlaintext bool
+	// DryRun reports what would be created or updated without changing the gateway
+	DryRun      bool
+	Concurrency int
+}
+
+// DetectHtpasswdFormat - Returns the storage format of an htpasswd password field
+func DetectHtpasswdFormat(password string) HtpasswdFormat {
+	switch {
+	case strings.HasPrefix(password, "$2a$"), strings.HasPrefix(password, "$2b$"), strings.HasPrefix(password, "$2y$"):
+		return HtpasswdBcrypt
+	case strings.HasPrefix(password, "$apr1$"):
+		return HtpasswdAPR1
+	case strings.HasPrefix(password, "{SHA}"):
+		return HtpasswdSHA1
+	case strings.HasPrefix(password, "$1$"):
+		return HtpasswdMD5Crypt
+	case strings.HasPrefix(password, "$5$"):
+		return HtpasswdSHA256Crypt
+	case strings.HasPrefix(password, "$6$"):
+		return HtpasswdSHA512Crypt
+	case desCryptPattern.MatchString(password):
+		return HtpasswdDESCrypt
+	default:
+		return HtpasswdPlain
+	}
+}
+
+// ParseHtpasswd - Parses htpasswd entries, skipping blank lines and comments
+func ParseHtpasswd(r io.Reader) ([]HtpasswdEntry, error) {
+	scanner := bufio.NewScanner(r)
+
+	var entries []HtpasswdEntry
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(strings.TrimSpace(text), "#") {
+			continue
+		}
+
+		username, password, ok := strings.Cut(text, ":")
+		if !ok || username == "" {
+			return nil, fmt.Errorf("line %d: expected user:password", line)
+		}
+
+		entries = append(entries, HtpasswdEntry{
+			Line:     line,
+			Username: username,
+			Password: password,
+			Format:   DetectHtpasswdFormat(password),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ImportHtpasswd - Creates or updates a consumer with the basic-auth plugin for every
+// htpasswd entry. Other plugins and fields of existing consumers are kept.
+//
+// The basic-auth plugin checks plaintext passwords, so entries stored as hashes cannot
+// be imported and are reported as skipped. Consumer usernames are the htpasswd user
+// names with characters APISIX does not allow replaced by an underscore; when two users
+// end up with the same consumer username, the later one fails naming both.
+func (c *ApiClient) ImportHtpasswd(r io.Reader, options HtpasswdImportOptions) (*ConsumerImportReport, error) {
+	entries, err := ParseHtpasswd(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []ConsumerImportRow
+	var skipped []ConsumerImportResult
+	// sources are the first entries imported as each consumer username
+	sources := map[string]HtpasswdEntry{}
+	for _, entry := range entries {
+		username := invalidConsumerUsername.ReplaceAllString(entry.Username, "_")
+
+		supported := entry.Format == HtpasswdPlain || (entry.Format == HtpasswdDESCrypt && options.AssumePlaintext)
+		if !supported {
+			skipped = append(skipped, ConsumerImportResult{
+				Line:     entry.Line,
+				Username: username,
+				Status:   ConsumerImportSkipped,
+				Err:      fmt.Errorf("%s passwords cannot be used by basic-auth, which needs the plaintext", entry.Format),
+			})
+			continue
+		}
+
+		if source, seen := sources[username]; seen && source.Username != entry.Username {
+			rows = append(rows, ConsumerImportRow{
+				Line:     entry.Line,
+				Consumer: Consumer{Username: &username},
+				Err:      fmt.Errorf("users %q and %q (line %d) both become consumer %s", entry.Username, source.Username, source.Line, username),
+			})
+			continue
+		}
+		if _, seen := sources[username]; !seen {
+			sources[username] = entry
+		}
+
+		plugins := map[string]interface{}{
+			BasicAuthPlugin: map[string]interface{}{
+				"username": entry.Username,
+				"password": entry.Password,
+			},
+		}
+
+		consumer := Consumer{
+			Username: &username,
+			GroupId:  options.GroupID,
+			Plugins:  &plugins,
+		}
+		if options.Labels != nil {
+			labels := make(map[string]string, len(options.Labels))
+			for key, value := range options.Labels {
+				labels[key] = value
+			}
+			consumer.Labels = &labels
+		}
+
+		rows = append(rows, ConsumerImportRow{Line: entry.Line, Consumer: consumer})
+	}
+
+	report := c.ImportConsumers(rows, ConsumerImportOptions{
+		Concurrency: options.Concurrency,
+		DryRun:      options.DryRun,
+		Merge:       true,
+	})
+
+	report.Results = append(report.Results, skipped...)
+	sort.Slice(report.Results, func(i, j int) bool {
+		return report.Results[i].Line < report.Results[j].Line
+	})
+
+	return report, nil
+}
@@ -0,0 +1,137 @@
+package api_client
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectHtpasswdFormat(t *testing.T) {
+	tests := []struct {
+		password string
+		want     HtpasswdFormat
+	}{
+		{password: "secret", want: HtpasswdPlain},
+		{password: "", want: HtpasswdPlain},
+		{password: "$2y$05$c4WoMPo3SXsafkva.HHa6uXQZWr7oboPiC2bT/r7q1BB8I2s0BRqC", want: HtpasswdBcrypt},
+		{password: "$2a$10$abcdefghijklmnopqrstuu", want: HtpasswdBcrypt},
+		{password: "$2b$10$abcdefghijklmnopqrstuu", want: HtpasswdBcrypt},
+		{password: "$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/", want: HtpasswdAPR1},
+		{password: "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", want: HtpasswdSHA1},
+		{password: "$1$saltsalt$qjXMvbEw8oaL.CzflDugX/", want: HtpasswdMD5Crypt},
+		{password: "$5$saltsalt$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZF7dyXd/3", want: HtpasswdSHA256Crypt},
+		{password: "$6$saltsalt$qFmFH.bQmmtXzyBY0s9v7Oicd2z4XSIecDzlB5KiA2/jctKu9YterLp8wwnSq.qc.eoxqOmSuNp2xS0ktL3nh/", want: HtpasswdSHA512Crypt},
+		{password: "rqXexS6ZhobKA", want: HtpasswdDESCrypt},
+		{password: "rqXexS6ZhobK", want: HtpasswdPlain},
+		{password: "rqXexS6Zhob!A", want: HtpasswdPlain},
+	}
+
+	for _, test := range tests {
+		if got := DetectHtpasswdFormat(test.password); got != test.want {
+			t.Errorf("DetectHtpasswdFormat(%q) = %s, want %s", test.password, got, test.want)
+		}
+	}
+}
+
+func TestParseHtpasswd(t *testing.T) {
+	input := strings.Join([]string{
+		"# users",
+		"jack:secret",
+		"",
+		"  # indented comment",
+		"jill:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\r",
+		"   ",
+		"joe:pass:with:colons",
+		"empty:",
+	}, "\n")
+
+	got, err := ParseHtpasswd(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []HtpasswdEntry{
+		{Line: 2, Username: "jack", Password: "secret", Format: HtpasswdPlain},
+		{Line: 5, Username: "jill", Password: "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", Format: HtpasswdSHA1},
+		{Line: 7, Username: "joe", Password: "pass:with:colons", Format: HtpasswdPlain},
+		{Line: 8, Username: "empty", Password: "", Format: HtpasswdPlain},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseHtpasswd() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseHtpasswdRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "no separator", input: "jack:secret\njill", wantErr: "line 2: expected user:password"},
+		{name: "no username", input: ":secret", wantErr: "line 1: expected user:password"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseHtpasswd(strings.NewReader(test.input))
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("ParseHtpasswd() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestImportHtpasswd(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+	admin.set("consumers/jack", map[string]interface{}{
+		"username": "jack", "desc": "kept", "plugins": map[string]interface{}{"key-auth": map[string]interface{}{"key": "jack-key"}},
+	})
+
+	input := strings.Join([]string{
+		"jack:secret",
+		"jo.e:first",
+		"hashed:$apr1$r31.....$HqJZimcKQFAMYayBlzkrA/",
+		"jo e:second",
+		"jack:again",
+	}, "\n")
+
+	report, err := client.ImportHtpasswd(strings.NewReader(input), HtpasswdImportOptions{Labels: map[string]string{"source": "htpasswd"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, result := range report.Results {
+		got = append(got, fmt.Sprintf("%d %s %s", result.Line, result.Username, result.Status))
+	}
+	want := []string{
+		"1 jack updated",
+		"2 jo_e created",
+		"3 hashed skipped",
+		"4 jo_e failed",
+		"5 jack failed",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("results %v, want %v", got, want)
+	}
+
+	if err := report.Results[3].Err; err == nil || !strings.Contains(err.Error(), `"jo e" and "jo.e" (line 2)`) {
+		t.Errorf("collision error = %v, want both source usernames", err)
+	}
+	if err := report.Results[4].Err; err == nil || !strings.Contains(err.Error(), "duplicate username") {
+		t.Errorf("duplicate error = %v, want a duplicate", err)
+	}
+
+	joe := admin.get("consumers/jo_e")
+	if joe == nil || mustJSON(t, joe["plugins"]) != `{"basic-auth":{"password":"first","username":"jo.e"}}` {
+		t.Errorf("consumer jo_e = %v, want the first user", joe)
+	}
+	jack := admin.get("consumers/jack")
+	if jack["desc"] != "kept" || mustJSON(t, jack["labels"]) != `{"source":"htpasswd"}` {
+		t.Errorf("consumer jack = %v, want it merged", jack)
+	}
+	if plugins, _ := jack["plugins"].(map[string]interface{}); plugins["key-auth"] == nil || plugins["basic-auth"] == nil {
+		t.Errorf("plugins of jack = %v, want key-auth kept and basic-auth added", plugins)
+	}
+}
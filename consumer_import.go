@@ -0,0 +1,390 @@
+package api_client
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// DefaultConsumerImportConcurrency is the number of consumers applied in parallel when
+// no concurrency is configured
+const DefaultConsumerImportConcurrency = 8
+
+type ConsumerImportStatus string
+
+const (
+	ConsumerImportCreated   ConsumerImportStatus = "created"
+	ConsumerImportUpdated   ConsumerImportStatus = "updated"
+	ConsumerImportUnchanged ConsumerImportStatus = "unchanged"
+	ConsumerImportFailed    ConsumerImportStatus = "failed"
+	ConsumerImportSkipped   ConsumerImportStatus = "skipped"
+)
+
+// ConsumerImportRow is a consumer read from an import file. Err is set when the row
+// could not be parsed.
+type ConsumerImportRow struct {
+	Line     int
+	Consumer Consumer
+	Err      error
+}
+
+type ConsumerImportOptions struct {
+	// Concurrency is the maximum number of consumers applied at the same time
+	Concurrency int
+	// DryRun reports what would be created or updated without changing the gateway
+	DryRun bool
+	// Merge applies rows on top of existing consumers instead of replacing them: labels
+	// and plugins are merged, desc and group_id are only changed when set in the row
+	Merge bool
+}
+
+type ConsumerImportResult struct {
+	Line     int
+	Username string
+	Status   ConsumerImportStatus
+	Err      error
+}
+
+type ConsumerImportReport struct {
+	Results []ConsumerImportResult
+}
+
+// Count - Returns the number of rows with the given status
+func (r *ConsumerImportReport) Count(status ConsumerImportStatus) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ReadConsumersCSV - Reads consumers from CSV with a header row.
+//
+// Supported columns are username, desc, labels (k1=v1;k2=v2), group_id and one column
+// per auth plugin field named plugin.field, e.g. key-auth.key or basic-auth.password.
+// Empty cells are left out of the consumer.
+func ReadConsumersCSV(r io.Reader) ([]ConsumerImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		header[i] = column
+
+		switch column {
+		case "username", "desc", "labels", "group_id":
+		default:
+			if plugin, field, ok := strings.Cut(column, "."); !ok || plugin == "" || field == "" {
+				return nil, fmt.Errorf("unsupported CSV column: %s", column)
+			}
+		}
+	}
+
+	var rows []ConsumerImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := ConsumerImportRow{Line: line}
+		if len(record) != len(header) {
+			row.Err = fmt.Errorf("expected %d fields, got %d", len(header), len(record))
+			rows = append(rows, row)
+			continue
+		}
+
+		row.Consumer, row.Err = consumerFromCSVRecord(header, record)
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// ReadConsumersJSONLines - Reads consumers from JSON Lines, one consumer object per line
+// in the Admin API format. Blank lines are skipped.
+func ReadConsumersJSONLines(r io.Reader) ([]ConsumerImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	var rows []ConsumerImportRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := ConsumerImportRow{Line: line}
+		row.Err = json.Unmarshal([]byte(text), &row.Consumer)
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// ImportConsumers - Creates or updates consumers with bounded concurrency and reports
+// the outcome of every row. Rows matching the consumer on the gateway are skipped.
+func (c *ApiClient) ImportConsumers(rows []ConsumerImportRow, options ConsumerImportOptions) *ConsumerImportReport {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConsumerImportConcurrency
+	}
+
+	report := &ConsumerImportReport{Results: make([]ConsumerImportResult, len(rows))}
+	firstLine := map[string]int{}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+
+	for i, row := range rows {
+		result := &report.Results[i]
+		result.Line = row.Line
+		result.Username = stringValue(row.Consumer.Username)
+
+		if row.Err != nil {
+			result.Status, result.Err = ConsumerImportFailed, row.Err
+			continue
+		}
+
+		if result.Username == "" {
+			result.Status, result.Err = ConsumerImportFailed, errors.New("username is required")
+			continue
+		}
+
+		if line, seen := firstLine[result.Username]; seen {
+			result.Status, result.Err = ConsumerImportFailed, fmt.Errorf("duplicate username, already imported from line %d", line)
+			continue
+		}
+		firstLine[result.Username] = row.Line
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(consumer Consumer) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result.Status, result.Err = c.importConsumer(consumer, options)
+		}(row.Consumer)
+	}
+
+	wg.Wait()
+	return report
+}
+
+func (c *ApiClient) importConsumer(consumer Consumer, options ConsumerImportOptions) (ConsumerImportStatus, error) {
+	status := ConsumerImportCreated
+
+	live, err := c.GetConsumer(*consumer.Username)
+	switch {
+	case err == nil:
+		if options.Merge {
+			consumer = mergeConsumer(*live, consumer)
+		}
+
+		unchanged, err := consumerUnchanged(*live, consumer)
+		if err != nil {
+			return ConsumerImportFailed, err
+		}
+		if unchanged {
+			return ConsumerImportUnchanged, nil
+		}
+		status = ConsumerImportUpdated
+	case !IsNotFound(err):
+		return ConsumerImportFailed, err
+	}
+
+	if options.DryRun {
+		return status, nil
+	}
+
+	if status == ConsumerImportCreated {
+		_, err = c.CreateConsumer(consumer)
+	} else {
+		_, err = c.UpdateConsumer(consumer)
+	}
+	if err != nil {
+		return ConsumerImportFailed, err
+	}
+
+	return status, nil
+}
+
+// mergeConsumer applies the fields set on an imported consumer on top of the live one
+func mergeConsumer(live, imported Consumer) Consumer {
+	merged := deepCopy(live)
+
+	if imported.Description != nil {
+		merged.Description = imported.Description
+	}
+
+	if imported.GroupId != nil {
+		merged.GroupId = imported.GroupId
+	}
+
+	if imported.Labels != nil {
+		labels := map[string]string{}
+		if merged.Labels != nil {
+			labels = *merged.Labels
+		}
+		for key, value := range *imported.Labels {
+			labels[key] = value
+		}
+		merged.Labels = &labels
+	}
+
+	if imported.Plugins != nil {
+		plugins := map[string]interface{}{}
+		if merged.Plugins != nil {
+			plugins = *merged.Plugins
+		}
+		for name, conf := range *imported.Plugins {
+			plugins[name] = conf
+		}
+		merged.Plugins = &plugins
+	}
+
+	return merged
+}
+
+// consumerUnchanged reports whether applying the desired consumer would not change the
+// live one. Plugin fields the gateway filled with defaults are ignored.
+func consumerUnchanged(live, desired Consumer) (bool, error) {
+	liveValue, err := toJSONValue(live)
+	if err != nil {
+		return false, err
+	}
+
+	desiredValue, err := toJSONValue(desired)
+	if err != nil {
+		return false, err
+	}
+
+	liveMap, _ := liveValue.(map[string]interface{})
+	desiredMap, _ := desiredValue.(map[string]interface{})
+	if len(liveMap) != len(desiredMap) {
+		return false, nil
+	}
+
+	livePlugins, _ := liveMap["plugins"].(map[string]interface{})
+	desiredPlugins, _ := desiredMap["plugins"].(map[string]interface{})
+	if len(livePlugins) != len(desiredPlugins) {
+		return false, nil
+	}
+
+	return jsonContains(liveValue, desiredValue), nil
+}
+
+// toJSONValue converts a value to its generic decoded JSON form
+func toJSONValue(value interface{}) (interface{}, error) {
+	rb, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	err = json.Unmarshal(rb, &decoded)
+	return decoded, err
+}
+
+// jsonContains reports whether every field set in want has the same value in have
+func jsonContains(have, want interface{}) bool {
+	wantMap, ok := want.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(have, want)
+	}
+
+	haveMap, ok := have.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	for key, value := range wantMap {
+		haveValue, exists := haveMap[key]
+		if !exists || !jsonContains(haveValue, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func consumerFromCSVRecord(header, record []string) (Consumer, error) {
+	consumer := Consumer{}
+	plugins := map[string]interface{}{}
+
+	for i, column := range header {
+		value := strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+
+		switch column {
+		case "username":
+			consumer.Username = &value
+		case "desc":
+			consumer.Description = &value
+		case "group_id":
+			consumer.GroupId = &value
+		case "labels":
+			labels, err := parseLabels(value)
+			if err != nil {
+				return consumer, err
+			}
+			consumer.Labels = &labels
+		default:
+			plugin, field, _ := strings.Cut(column, ".")
+			conf, ok := plugins[plugin].(map[string]interface{})
+			if !ok {
+				conf = map[string]interface{}{}
+				plugins[plugin] = conf
+			}
+			conf[field] = value
+		}
+	}
+
+	if len(plugins) > 0 {
+		consumer.Plugins = &plugins
+	}
+
+	return consumer, nil
+}
+
+// parseLabels parses labels written as k1=v1;k2=v2
+func parseLabels(value string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(value, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, labelValue, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", pair)
+		}
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(labelValue)
+	}
+
+	return labels, nil
+}
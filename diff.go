@@ -0,0 +1,358 @@
+package api_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// Change is a difference between two versions of a resource at a JSON path such as
+// $.plugins['limit-count'].count
+type Change struct {
+	Path string      `json:"path"`
+	Type ChangeType  `json:"type"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+type Changes []Change
+
+const (
+	diffColorRed   = "\x1b[31m"
+	diffColorGreen = "\x1b[32m"
+	diffColorCyan  = "\x1b[36m"
+	diffColorReset = "\x1b[0m"
+)
+
+// diffContextLines is the number of unchanged lines around each hunk of a unified diff
+const diffContextLines = 3
+
+var jsonPathIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Diff - Compares two versions of a resource, such as a fetched Route and a desired one.
+// Differences that do not change the resource are ignored: nil versus empty values, key
+// order, server managed fields like create_time and the order of set-like fields like
+// methods and hosts. Changes are ordered by path.
+func Diff[T any](a, b T) (Changes, error) {
+	original, err := normalizeForDiff(a)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := normalizeForDiff(b)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := Changes{}
+	diffValues("$", original, updated, &changes)
+	return changes, nil
+}
+
+// Empty - Reports whether there are no changes
+func (c Changes) Empty() bool {
+	return len(c) == 0
+}
+
+// String - Returns one line per change, e.g. "~ $.uri: "/a" -> "/b""
+func (c Changes) String() string {
+	var b strings.Builder
+	for _, change := range c {
+		switch change.Type {
+		case ChangeAdded:
+			fmt.Fprintf(&b, "+ %s: %s\n", change.Path, diffValueString(change.New))
+		case ChangeRemoved:
+			fmt.Fprintf(&b, "- %s: %s\n", change.Path, diffValueString(change.Old))
+		case ChangeModified:
+			fmt.Fprintf(&b, "~ %s: %s -> %s\n", change.Path, diffValueString(change.Old), diffValueString(change.New))
+		}
+	}
+	return b.String()
+}
+
+// RenderDiff - Renders the normalized versions of a resource as a unified diff of their
+// indented JSON, with removed lines in red and added lines in green when color is set.
+// Returns an empty string when Diff finds no changes.
+func RenderDiff[T any](a, b T, color bool) (string, error) {
+	original, err := normalizeForDiff(a)
+	if err != nil {
+		return "", err
+	}
+
+	updated, err := normalizeForDiff(b)
+	if err != nil {
+		return "", err
+	}
+
+	if reflect.DeepEqual(original, updated) {
+		return "", nil
+	}
+
+	oldLines, err := diffLines(original)
+	if err != nil {
+		return "", err
+	}
+
+	newLines, err := diffLines(updated)
+	if err != nil {
+		return "", err
+	}
+
+	return unifiedDiff(oldLines, newLines, color), nil
+}
+
+// normalizeForDiff converts a resource to its generic canonical form without server
+// managed fields and empty values
+func normalizeForDiff(value interface{}) (interface{}, error) {
+	encoded, err := CanonicalJSON(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+
+	if object, ok := decoded.(map[string]interface{}); ok {
+		for _, field := range serverManagedFields {
+			delete(object, field)
+		}
+	}
+
+	normalized, _ := dropEmpty(decoded)
+	return normalized, nil
+}
+
+// dropEmpty removes nulls, empty objects and empty arrays, and reports whether the value
+// itself is empty. Plugins with an empty config stay, as they are still enabled.
+func dropEmpty(value interface{}) (interface{}, bool) {
+	return dropEmptyField(value, "")
+}
+
+func dropEmptyField(value interface{}, field string) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, true
+	case map[string]interface{}:
+		for key, item := range v {
+			normalized, empty := dropEmptyField(item, key)
+			if empty && (field != "plugins" || normalized == nil) {
+				delete(v, key)
+			} else {
+				v[key] = normalized
+			}
+		}
+		return v, len(v) == 0
+	case []interface{}:
+		return v, len(v) == 0
+	}
+	return value, false
+}
+
+func diffValues(path string, original, updated interface{}, changes *Changes) {
+	oldObject, oldIsObject := original.(map[string]interface{})
+	newObject, newIsObject := updated.(map[string]interface{})
+	if oldIsObject && newIsObject {
+		keys := make([]string, 0, len(oldObject)+len(newObject))
+		for key := range oldObject {
+			keys = append(keys, key)
+		}
+		for key := range newObject {
+			if _, exists := oldObject[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			diffValues(jsonPathChild(path, key), oldObject[key], newObject[key], changes)
+		}
+		return
+	}
+
+	oldArray, oldIsArray := original.([]interface{})
+	newArray, newIsArray := updated.([]interface{})
+	if oldIsArray && newIsArray {
+		for i := 0; i < len(oldArray) || i < len(newArray); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(oldArray):
+				*changes = append(*changes, Change{Path: itemPath, Type: ChangeAdded, New: newArray[i]})
+			case i >= len(newArray):
+				*changes = append(*changes, Change{Path: itemPath, Type: ChangeRemoved, Old: oldArray[i]})
+			default:
+				diffValues(itemPath, oldArray[i], newArray[i], changes)
+			}
+		}
+		return
+	}
+
+	switch {
+	case original == nil && updated == nil:
+	case original == nil:
+		*changes = append(*changes, Change{Path: path, Type: ChangeAdded, New: updated})
+	case updated == nil:
+		*changes = append(*changes, Change{Path: path, Type: ChangeRemoved, Old: original})
+	case !reflect.DeepEqual(original, updated):
+		*changes = append(*changes, Change{Path: path, Type: ChangeModified, Old: original, New: updated})
+	}
+}
+
+func jsonPathChild(path, key string) string {
+	if jsonPathIdentifier.MatchString(key) {
+		return path + "." + key
+	}
+	return fmt.Sprintf("%s['%s']", path, strings.ReplaceAll(key, "'", `\'`))
+}
+
+func diffValueString(value interface{}) string {
+	rb, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(rb)
+}
+
+func diffLines(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	encoded, err := CanonicalJSONIndent(value, "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(string(encoded), "\n"), nil
+}
+
+// diffOperation is a line of a diff: ' ' kept, '-' removed or '+' added
+type diffOperation struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff renders a line diff based on the longest common subsequence
+func unifiedDiff(original, updated []string, color bool) string {
+	// common[i][j] is the length of the longest common subsequence of original[i:] and updated[j:]
+	common := make([][]int, len(original)+1)
+	for i := range common {
+		common[i] = make([]int, len(updated)+1)
+	}
+	for i := len(original) - 1; i >= 0; i-- {
+		for j := len(updated) - 1; j >= 0; j-- {
+			if original[i] == updated[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	operations := []diffOperation{}
+	i, j := 0, 0
+	for i < len(original) || j < len(updated) {
+		switch {
+		case i < len(original) && j < len(updated) && original[i] == updated[j]:
+			operations = append(operations, diffOperation{' ', original[i]})
+			i++
+			j++
+		case i < len(original) && (j == len(updated) || common[i+1][j] >= common[i][j+1]):
+			operations = append(operations, diffOperation{'-', original[i]})
+			i++
+		default:
+			operations = append(operations, diffOperation{'+', updated[j]})
+			j++
+		}
+	}
+
+	paint := func(code, text string) string {
+		if !color {
+			return text
+		}
+		return code + text + diffColorReset
+	}
+
+	var b strings.Builder
+	b.WriteString(paint(diffColorRed, "--- a") + "\n")
+	b.WriteString(paint(diffColorGreen, "+++ b") + "\n")
+
+	for start := 0; start < len(operations); {
+		if operations[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		// Extend the hunk while changes are separated by at most twice the context
+		hunkStart := max(start-diffContextLines, 0)
+		end := start
+		for next := start; next < len(operations); next++ {
+			if operations[next].kind != ' ' {
+				end = next
+			} else if next-end > 2*diffContextLines {
+				break
+			}
+		}
+		hunkEnd := min(end+diffContextLines+1, len(operations))
+
+		oldStart, newStart := 1, 1
+		for _, operation := range operations[:hunkStart] {
+			if operation.kind != '+' {
+				oldStart++
+			}
+			if operation.kind != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, operation := range operations[hunkStart:hunkEnd] {
+			if operation.kind != '+' {
+				oldCount++
+			}
+			if operation.kind != '-' {
+				newCount++
+			}
+		}
+
+		b.WriteString(paint(diffColorCyan, fmt.Sprintf("@@ -%s +%s @@", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))) + "\n")
+		for _, operation := range operations[hunkStart:hunkEnd] {
+			line := string(operation.kind) + operation.line
+			switch operation.kind {
+			case '-':
+				line = paint(diffColorRed, line)
+			case '+':
+				line = paint(diffColorGreen, line)
+			}
+			b.WriteString(line + "\n")
+		}
+
+		start = hunkEnd
+	}
+
+	return b.String()
+}
+
+// hunkRange formats the lines of one side of a hunk like diff -u: the count is left out
+// when it is 1, and an empty range starts at the line before it
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprint(start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, count)
+}
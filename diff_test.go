@@ -0,0 +1,129 @@
+package api_client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	numbered := func(n int) []string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = string(rune('0' + i + 1))
+		}
+		return lines
+	}
+	replaced := func(lines []string, replacements map[int]string) []string {
+		result := append([]string{}, lines...)
+		for i, line := range replacements {
+			result[i] = line
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		original []string
+		updated  []string
+		want     []string
+	}{
+		{
+			name:     "single line",
+			original: []string{"a"},
+			updated:  []string{"z"},
+			want:     []string{"@@ -1 +1 @@", "-a", "+z"},
+		},
+		{
+			name:     "added to empty",
+			original: nil,
+			updated:  []string{"x", "y"},
+			want:     []string{"@@ -0,0 +1,2 @@", "+x", "+y"},
+		},
+		{
+			name:     "removed to empty",
+			original: []string{"x", "y"},
+			updated:  nil,
+			want:     []string{"@@ -1,2 +0,0 @@", "-x", "-y"},
+		},
+		{
+			name:     "longest common subsequence",
+			original: []string{"a", "b", "c", "d"},
+			updated:  []string{"a", "c", "d", "e"},
+			want:     []string{"@@ -1,4 +1,4 @@", " a", "-b", " c", " d", "+e"},
+		},
+		{
+			name:     "context around a change",
+			original: numbered(9),
+			updated:  replaced(numbered(9), map[int]string{4: "x"}),
+			want:     []string{"@@ -2,7 +2,7 @@", " 2", " 3", " 4", "-5", "+x", " 6", " 7", " 8"},
+		},
+		{
+			name:     "changes twice the context apart share a hunk",
+			original: numbered(8),
+			updated:  replaced(numbered(8), map[int]string{0: "x", 7: "y"}),
+			want:     []string{"@@ -1,8 +1,8 @@", "-1", "+x", " 2", " 3", " 4", " 5", " 6", " 7", "-8", "+y"},
+		},
+		{
+			name:     "changes further apart get separate hunks",
+			original: numbered(9),
+			updated:  replaced(numbered(9), map[int]string{0: "x", 8: "y"}),
+			want: []string{
+				"@@ -1,4 +1,4 @@", "-1", "+x", " 2", " 3", " 4",
+				"@@ -6,4 +6,4 @@", " 6", " 7", " 8", "-9", "+y",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			want := "--- a\n+++ b\n" + strings.Join(test.want, "\n") + "\n"
+			if got := unifiedDiff(test.original, test.updated, false); got != want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffColor(t *testing.T) {
+	got := unifiedDiff([]string{"a", "b"}, []string{"a", "c"}, true)
+	want := diffColorRed + "--- a" + diffColorReset + "\n" +
+		diffColorGreen + "+++ b" + diffColorReset + "\n" +
+		diffColorCyan + "@@ -1,2 +1,2 @@" + diffColorReset + "\n" +
+		" a\n" +
+		diffColorRed + "-b" + diffColorReset + "\n" +
+		diffColorGreen + "+c" + diffColorReset + "\n"
+	if got != want {
+		t.Errorf("unifiedDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	original := map[string]interface{}{"uri": "/a", "methods": []interface{}{"GET", "POST"}}
+
+	got, err := RenderDiff(original, map[string]interface{}{"uri": "/a", "methods": []interface{}{"POST", "GET"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("RenderDiff() of equal resources = %q, want none", got)
+	}
+
+	got, err = RenderDiff(original, map[string]interface{}{"uri": "/b", "methods": []interface{}{"POST", "GET"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"--- a",
+		"+++ b",
+		"@@ -3,5 +3,5 @@",
+		`     "GET",`,
+		`     "POST"`,
+		`   ],`,
+		`-  "uri": "/a"`,
+		`+  "uri": "/b"`,
+		` }`,
+	}, "\n") + "\n"
+	if got != want {
+		t.Errorf("RenderDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,358 @@
+package api_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordedMutation is a write a dry-run client would have sent. Body is sent as-is, so it
+// may hold credentials.
+type RecordedMutation struct {
+	Method string       `json:"method"`
+	URL    string       `json:"url"`
+	Kind   ResourceKind `json:"kind"`
+	// ID is empty when the gateway would generate it, e.g. for CreateRoute
+	ID string `json:"id,omitempty"`
+	// SubPath addresses part of the resource, e.g. plugins/limit-count or credentials/cred-1
+	SubPath string          `json:"sub_path,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// String - Returns the method and resource, e.g. "PUT routes/api"
+func (m RecordedMutation) String() string {
+	target := string(m.Kind)
+	if m.ID != "" {
+		target += "/" + m.ID
+	}
+	if m.SubPath != "" {
+		target += "/" + m.SubPath
+	}
+	return fmt.Sprintf("%s %s", m.Method, target)
+}
+
+type dryRunRecorder struct {
+	mu        sync.Mutex
+	mutations []RecordedMutation
+	generated int
+	// written holds the resources written so far by etcd key, nil for deleted ones
+	written map[string]map[string]interface{}
+}
+
+// dryRunRequiredFields lists the fields a written object of a kind needs. Each entry lists
+// alternatives of which at least one must be set.
+var dryRunRequiredFields = map[ResourceKind][][]string{
+	RouteKind:         {{"uri", "uris"}, {"plugins", "script", "upstream", "upstream_id", "service_id", "plugin_config_id"}},
+	UpstreamKind:      {{"nodes", "service_name"}},
+	SSLKind:           {{"cert"}, {"key"}},
+	ConsumerKind:      {{"username"}},
+	ConsumerGroupKind: {{"plugins"}},
+	PluginConfigKind:  {{"plugins"}},
+	GlobalRuleKind:    {{"plugins"}},
+	ProtoKind:         {{"content"}},
+}
+
+// NewDryRunClient - Creates a client that reads from the gateway but never writes to it.
+// Every Create*, Update*, Delete* and other mutating call is validated and recorded
+// instead of sent, and answered with the response the gateway would plausibly give.
+// Reads of a resource written earlier in the dry run return the recorded write, or a 404
+// when it was deleted; other reads, including lists, go to the gateway.
+func NewDryRunClient(endpoint, apiKey *string) (*ApiClient, error) {
+	c, err := NewClient(endpoint, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	c.dryRun = &dryRunRecorder{}
+	return c, nil
+}
+
+// IsDryRun - Reports whether the client records writes instead of sending them
+func (c *ApiClient) IsDryRun() bool {
+	return c.dryRun != nil
+}
+
+// RecordedMutations - Returns the writes a dry-run client recorded, in call order
+func (c *ApiClient) RecordedMutations() []RecordedMutation {
+	if c.dryRun == nil {
+		return nil
+	}
+
+	c.dryRun.mu.Lock()
+	defer c.dryRun.mu.Unlock()
+	return append([]RecordedMutation(nil), c.dryRun.mutations...)
+}
+
+// ResetRecordedMutations - Clears the writes a dry-run client recorded, so reads see the
+// gateway again
+func (c *ApiClient) ResetRecordedMutations() {
+	if c.dryRun == nil {
+		return
+	}
+
+	c.dryRun.mu.Lock()
+	defer c.dryRun.mu.Unlock()
+	c.dryRun.mutations = nil
+	c.dryRun.written = nil
+}
+
+// remember stores the resource a write left at an etcd key, nil for a delete
+func (r *dryRunRecorder) remember(key string, value map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.written == nil {
+		r.written = map[string]map[string]interface{}{}
+	}
+	r.written[key] = value
+}
+
+// recorded returns the resource the writes so far left at an etcd key. It reports false
+// when no write touched the key; a nil resource means it was deleted.
+func (r *dryRunRecorder) recorded(key string) (map[string]interface{}, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if value, found := r.written[key]; found {
+		return value, true
+	}
+
+	// Deleting a consumer deletes its credentials
+	for parent := path.Dir(key); parent != "/apisix" && parent != "/"; parent = path.Dir(parent) {
+		if value, found := r.written[parent]; found && value == nil {
+			return nil, true
+		}
+	}
+
+	return nil, false
+}
+
+// dryRunRead answers a read of a resource written earlier in the dry run. It reports false
+// when the read has to go to the gateway.
+func (c *ApiClient) dryRunRead(req *http.Request) (int, []byte, bool) {
+	kind, id, subPath, err := parseAdminPath(req.URL.Path)
+	if err != nil || id == "" {
+		return 0, nil, false
+	}
+
+	key := adminKey(kind, id, subPath)
+	value, found := c.dryRun.recorded(key)
+	if !found {
+		return 0, nil, false
+	}
+
+	if value == nil {
+		body, _ := json.Marshal(map[string]interface{}{"message": "Key not found"})
+		return http.StatusNotFound, body, true
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"key": key, "value": value})
+	if err != nil {
+		return 0, nil, false
+	}
+	return http.StatusOK, body, true
+}
+
+// adminKey returns the etcd key of an Admin API resource, e.g. /apisix/routes/api
+func adminKey(kind ResourceKind, id, subPath string) string {
+	key := fmt.Sprintf("/apisix/%s/%s", kind, id)
+	if subPath != "" {
+		key += "/" + subPath
+	}
+	return key
+}
+
+// validateDryRunObject checks that a written object has the fields the gateway requires
+func validateDryRunObject(kind ResourceKind, id, subPath string, object map[string]interface{}) error {
+	target := string(kind)
+	if id != "" {
+		target += "/" + id
+	}
+
+	if object == nil {
+		return fmt.Errorf("dry run: %s needs a JSON object body", target)
+	}
+
+	required := dryRunRequiredFields[kind]
+	if subPath != "" {
+		// Credentials are the only sub-resources written whole
+		required = [][]string{{"plugins"}}
+		target += "/" + subPath
+	}
+	if kind == SSLKind && subPath == "" && object["type"] != "client" {
+		required = append(required, []string{"sni", "snis"})
+	}
+
+	for _, alternatives := range required {
+		set := false
+		for _, field := range alternatives {
+			if value, exists := object[field]; exists && value != nil {
+				set = true
+				break
+			}
+		}
+		if !set {
+			return fmt.Errorf("dry run: %s needs one of %s", target, strings.Join(alternatives, ", "))
+		}
+	}
+
+	return nil
+}
+
+func isMutatingMethod(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// parseAdminPath splits an Admin API URL path into kind, ID and sub-path. Secret IDs
+// include their manager, e.g. vault/1.
+func parseAdminPath(path string) (ResourceKind, string, string, error) {
+	rest, found := strings.CutPrefix(path, "/apisix/admin/")
+	if !found {
+		return "", "", "", fmt.Errorf("not an Admin API path: %s", path)
+	}
+
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	kind := ResourceKind(segments[0])
+	segments = segments[1:]
+
+	idSegments := 1
+	if kind == SecretKind {
+		idSegments = 2
+	}
+	if len(segments) < idSegments {
+		return kind, strings.Join(segments, "/"), "", nil
+	}
+
+	return kind, strings.Join(segments[:idSegments], "/"), strings.Join(segments[idSegments:], "/"), nil
+}
+
+// dryRunRequest validates and records a write and returns a plausible response body
+func (c *ApiClient) dryRunRequest(req *http.Request) ([]byte, error) {
+	kind, id, subPath, err := parseAdminPath(req.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var value interface{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &value); err != nil {
+			return nil, fmt.Errorf("dry run: invalid request body for %s %s: %w", req.Method, req.URL.Path, err)
+		}
+	}
+
+	object, _ := value.(map[string]interface{})
+	if kind == ConsumerKind && id == "" && req.Method == http.MethodPut {
+		// Consumers are identified by the username in the body
+		id, _ = object["username"].(string)
+		if id == "" {
+			return nil, fmt.Errorf("dry run: consumer without username")
+		}
+	}
+
+	if id == "" && req.Method != http.MethodPost {
+		return nil, fmt.Errorf("dry run: %s %s has no resource id", req.Method, req.URL.Path)
+	}
+
+	// PUT and POST write whole resources and credentials, PATCH writes parts of them
+	writesWhole := (req.Method == http.MethodPut || req.Method == http.MethodPost) &&
+		(subPath == "" || strings.HasPrefix(subPath, "credentials/"))
+	if writesWhole {
+		if err := validateDryRunObject(kind, id, subPath, object); err != nil {
+			return nil, err
+		}
+	}
+
+	mutation := RecordedMutation{Method: req.Method, URL: req.URL.String(), Kind: kind, ID: id, SubPath: subPath}
+	if len(body) > 0 {
+		mutation.Body = json.RawMessage(body)
+	}
+
+	c.dryRun.mu.Lock()
+	c.dryRun.mutations = append(c.dryRun.mutations, mutation)
+	if id == "" {
+		c.dryRun.generated++
+		id = fmt.Sprintf("dry-run-%d", c.dryRun.generated)
+	}
+	c.dryRun.mu.Unlock()
+
+	key := adminKey(kind, id, subPath)
+
+	if req.Method == http.MethodDelete {
+		c.dryRun.remember(key, nil)
+		return json.Marshal(map[string]interface{}{"key": key, "deleted": "1"})
+	}
+
+	// rememberAt is the key of the whole resource the write leaves, empty when unknown
+	rememberAt := ""
+	if req.Method == http.MethodPatch {
+		var complete bool
+		object, complete = c.dryRunPatchedValue(kind, id, subPath, value)
+		if complete {
+			rememberAt = adminKey(kind, id, "")
+		}
+	} else if writesWhole {
+		rememberAt = key
+		switch {
+		case subPath != "":
+			object["id"] = path.Base(subPath)
+		case kind != ConsumerKind:
+			object["id"] = path.Base(id)
+		}
+	}
+
+	now := time.Now().Unix()
+	if object != nil {
+		if _, set := object["create_time"]; !set {
+			object["create_time"] = now
+		}
+		object["update_time"] = now
+	}
+	if rememberAt != "" {
+		c.dryRun.remember(rememberAt, object)
+	}
+
+	return json.Marshal(map[string]interface{}{"key": key, "value": object})
+}
+
+// dryRunPatchedValue applies a patch to the current resource, or returns the patch alone
+// when the resource cannot be read. It reports whether the result is the whole resource.
+func (c *ApiClient) dryRunPatchedValue(kind ResourceKind, id, subPath string, patch interface{}) (map[string]interface{}, bool) {
+	current, err := c.getResource(kind, id)
+	if err != nil {
+		object, _ := patch.(map[string]interface{})
+		return object, false
+	}
+
+	if subPath == "" {
+		patchObject, _ := patch.(map[string]interface{})
+		return mergeJSONObjects(current, patchObject), true
+	}
+
+	parent := current
+	segments := strings.Split(subPath, "/")
+	for _, segment := range segments[:len(segments)-1] {
+		child, ok := parent[segment].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			parent[segment] = child
+		}
+		parent = child
+	}
+	parent[segments[len(segments)-1]] = patch
+
+	return current, true
+}
@@ -0,0 +1,192 @@
+package api_client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newDryRunClient returns a dry-run client of a fake Admin API
+func newDryRunClient(t *testing.T) (*fakeAdmin, *ApiClient) {
+	t.Helper()
+
+	admin, client := newFakeAdmin(t)
+	dryRun, err := NewDryRunClient(&client.Endpoint, &client.APIKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return admin, dryRun
+}
+
+// recordedPlan returns the recorded mutations of a client as text
+func recordedPlan(client *ApiClient) []string {
+	plan := []string{}
+	for _, mutation := range client.RecordedMutations() {
+		plan = append(plan, mutation.String())
+	}
+	return plan
+}
+
+func TestDryRunRecordsWrites(t *testing.T) {
+	admin, client := newDryRunClient(t)
+	admin.set("routes/legacy", map[string]interface{}{"id": "legacy", "uri": "/legacy/*", "upstream_id": "backend"})
+
+	uri, upstreamID := "/api/*", "backend"
+	created, err := client.CreateRoute(Route{URI: &uri, UpstreamId: &upstreamID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == nil || *created.ID != "dry-run-1" {
+		t.Errorf("created route id = %v, want dry-run-1", created.ID)
+	}
+
+	if _, err := client.UpdateRoute("api", Route{URI: &uri, UpstreamId: &upstreamID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteRoute("legacy"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"POST routes", "PUT routes/api", "DELETE routes/legacy"}
+	if plan := recordedPlan(client); !reflect.DeepEqual(plan, want) {
+		t.Errorf("recorded %v, want %v", plan, want)
+	}
+	if mutation := client.RecordedMutations()[1]; !strings.Contains(string(mutation.Body), `"upstream_id":"backend"`) {
+		t.Errorf("recorded body %s, want the request body", mutation.Body)
+	}
+
+	if writes := admin.writes(); len(writes) != 0 {
+		t.Errorf("gateway received writes %v", writes)
+	}
+	if admin.get("routes/legacy") == nil {
+		t.Error("gateway route was deleted")
+	}
+}
+
+func TestDryRunReadsRecordedWrites(t *testing.T) {
+	admin, client := newDryRunClient(t)
+	admin.set("routes/legacy", map[string]interface{}{"id": "legacy", "uri": "/legacy/*", "upstream_id": "backend"})
+	admin.set("routes/other", map[string]interface{}{"id": "other", "uri": "/other/*", "upstream_id": "backend"})
+
+	uri, upstreamID := "/api/*", "backend"
+	created, err := client.CreateRoute(Route{URI: &uri, UpstreamId: &upstreamID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteRoute("legacy"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.patchResource(RouteKind, "other", "", map[string]interface{}{"name": "renamed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	route, err := client.GetRoute(*created.ID)
+	if err != nil {
+		t.Fatalf("GetRoute(%s) error = %v, want the recorded route", *created.ID, err)
+	}
+	if *route.URI != uri {
+		t.Errorf("recorded route uri = %s, want %s", *route.URI, uri)
+	}
+
+	if _, err := client.GetRoute("legacy"); !IsNotFound(err) {
+		t.Errorf("GetRoute(legacy) error = %v, want not found after the recorded delete", err)
+	}
+
+	other, err := client.GetRoute("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Name == nil || *other.Name != "renamed" || *other.URI != "/other/*" {
+		t.Errorf("patched route = %+v, want the gateway route with the patch applied", other)
+	}
+
+	client.ResetRecordedMutations()
+	if _, err := client.GetRoute("legacy"); err != nil {
+		t.Errorf("GetRoute(legacy) after reset error = %v, want the gateway route", err)
+	}
+}
+
+func TestDryRunValidation(t *testing.T) {
+	uri, upstreamID, cert, key := "/api/*", "backend", "cert", "key"
+
+	tests := []struct {
+		name  string
+		write func(client *ApiClient) error
+		want  string
+	}{
+		{
+			name: "route without uri",
+			write: func(client *ApiClient) error {
+				_, err := client.CreateRoute(Route{UpstreamId: &upstreamID})
+				return err
+			},
+			want: "needs one of uri, uris",
+		},
+		{
+			name: "route without upstream",
+			write: func(client *ApiClient) error {
+				_, err := client.UpdateRoute("api", Route{URI: &uri})
+				return err
+			},
+			want: "routes/api needs one of plugins",
+		},
+		{
+			name: "upstream without nodes",
+			write: func(client *ApiClient) error {
+				_, err := client.UpdateUpstream("backend", Upstream{})
+				return err
+			},
+			want: "upstreams/backend needs one of nodes, service_name",
+		},
+		{
+			name: "certificate without snis",
+			write: func(client *ApiClient) error {
+				_, err := client.CreateSslCertificate(SSLCertificate{Certificate: &cert, PrivateKey: &key})
+				return err
+			},
+			want: "needs one of sni, snis",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, client := newDryRunClient(t)
+
+			err := test.write(client)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("error = %v, want %q", err, test.want)
+			}
+			if plan := recordedPlan(client); len(plan) != 0 {
+				t.Errorf("recorded %v for an invalid write", plan)
+			}
+		})
+	}
+}
+
+func TestDryRunRotateCertificate(t *testing.T) {
+	admin, client := newDryRunClient(t)
+	for i, id := range []string{"old-1", "old-2"} {
+		oldCert, oldKey := testCertificate(t, int64(i+1), "example.com")
+		admin.set("ssls/"+id, map[string]interface{}{
+			"id": id, "cert": oldCert, "key": oldKey, "snis": []interface{}{"example.com"}, "type": "server", "status": 1,
+		})
+	}
+	newCert, newKey := testCertificate(t, 10, "example.com")
+
+	result, err := client.RotateCertificateWithOptions("example.com", newCert, newKey, CertificateRotationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.NewCertificateID != "dry-run-1" {
+		t.Errorf("new certificate id = %s, want dry-run-1", result.NewCertificateID)
+	}
+
+	want := []string{"POST ssls", "PATCH ssls/old-1", "PATCH ssls/old-2", "DELETE ssls/old-1", "DELETE ssls/old-2"}
+	if plan := recordedPlan(client); !reflect.DeepEqual(plan, want) {
+		t.Errorf("recorded %v, want %v", plan, want)
+	}
+	if writes := admin.writes(); len(writes) != 0 {
+		t.Errorf("gateway received writes %v", writes)
+	}
+}
@@ -0,0 +1,279 @@
+package api_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ExportFormat string
+
+const (
+	ExportYAML ExportFormat = "yaml"
+	ExportJSON ExportFormat = "json"
+)
+
+// ExportSecrets controls how credentials and private keys are exported
+type ExportSecrets string
+
+const (
+	// ExportSecretsInclude keeps credentials and private keys as-is
+	ExportSecretsInclude ExportSecrets = "include"
+	// ExportSecretsRedact replaces credentials and private keys with RedactedValue
+	ExportSecretsRedact ExportSecrets = "redact"
+	// ExportSecretsExclude leaves credentials and private keys out
+	ExportSecretsExclude ExportSecrets = "exclude"
+)
+
+type ExportOptions struct {
+	// Format defaults to ExportYAML
+	Format ExportFormat
+	// IncludeServerFields adds create_time and update_time. LoadState ignores them.
+	IncludeServerFields bool
+	// Secrets defaults to ExportSecretsRedact. Redacted or excluded values must be filled
+	// in again before the export is applied to a gateway.
+	Secrets ExportSecrets
+}
+
+// Export - Writes every object of the gateway to w as a single State document. Objects
+// are ordered by ID and fields by name, so unchanged gateways export to the same bytes.
+func (c *ApiClient) Export(w io.Writer, options ExportOptions) error {
+	objects, err := c.exportObjects(options)
+	if err != nil {
+		return err
+	}
+
+	return encodeExport(w, stateDocument(objects), options.Format)
+}
+
+// ExportDir - Writes every object of the gateway to its own file in a directory per kind,
+// e.g. routes/api.yaml. Files of objects that no longer exist are not removed, so export
+// into an empty directory. LoadStateDir reads the tree back.
+func (c *ApiClient) ExportDir(dir string, options ExportOptions) error {
+	objects, err := c.exportObjects(options)
+	if err != nil {
+		return err
+	}
+
+	extension := ".yaml"
+	if options.Format == ExportJSON {
+		extension = ".json"
+	}
+
+	for _, kind := range stateKinds {
+		if len(objects[kind]) == 0 {
+			continue
+		}
+
+		kindDir := filepath.Join(dir, string(kind))
+		if err := os.MkdirAll(kindDir, 0o755); err != nil {
+			return err
+		}
+
+		for _, id := range sortedIDs(objects[kind]) {
+			buf := &bytes.Buffer{}
+			if err := encodeExport(buf, objects[kind][id], options.Format); err != nil {
+				return fmt.Errorf("failed to encode %s %s: %w", kind, id, err)
+			}
+
+			path := filepath.Join(kindDir, url.PathEscape(id)+extension)
+			if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// exportObjects returns the canonical form of every object of the gateway, per kind and ID
+func (c *ApiClient) exportObjects(options ExportOptions) (map[ResourceKind]map[string]map[string]interface{}, error) {
+	if options.Format != "" && options.Format != ExportYAML && options.Format != ExportJSON {
+		return nil, fmt.Errorf("unsupported export format: %q", options.Format)
+	}
+
+	secrets := options.Secrets
+	if secrets == "" {
+		secrets = ExportSecretsRedact
+	}
+	if secrets != ExportSecretsInclude && secrets != ExportSecretsRedact && secrets != ExportSecretsExclude {
+		return nil, fmt.Errorf("unsupported export secrets mode: %q", options.Secrets)
+	}
+
+	state, err := c.FetchState()
+	if err != nil {
+		return nil, err
+	}
+
+	if secrets != ExportSecretsInclude {
+		state = state.Redact()
+	}
+
+	resources, err := state.resources()
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[ResourceKind]map[string]map[string]interface{}, len(resources))
+	for kind, byID := range resources {
+		objects[kind] = make(map[string]map[string]interface{}, len(byID))
+
+		for id, resource := range byID {
+			if secrets == ExportSecretsExclude {
+				removeRedactedValues(resource)
+			}
+
+			object, err := canonicalObject(resource)
+			if err != nil {
+				return nil, err
+			}
+			objects[kind][id] = object
+		}
+	}
+
+	if options.IncludeServerFields {
+		if err := c.addServerFields(objects); err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// canonicalObject round trips a resource through canonical JSON to sort set-like fields
+func canonicalObject(resource map[string]interface{}) (map[string]interface{}, error) {
+	encoded, err := CanonicalJSON(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := decodeJSONNumbers(json.RawMessage(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	object, _ := exportNumbers(decoded).(map[string]interface{})
+	return object, nil
+}
+
+// stateDocument lays objects out as a State document, each kind ordered by ID
+func stateDocument(objects map[ResourceKind]map[string]map[string]interface{}) map[string]interface{} {
+	document := map[string]interface{}{}
+	for _, kind := range stateKinds {
+		if len(objects[kind]) == 0 {
+			continue
+		}
+
+		items := make([]interface{}, 0, len(objects[kind]))
+		for _, id := range sortedIDs(objects[kind]) {
+			items = append(items, objects[kind][id])
+		}
+		document[string(kind)] = items
+	}
+
+	return document
+}
+
+// addServerFields copies the server managed fields the typed resources do not keep
+func (c *ApiClient) addServerFields(objects map[ResourceKind]map[string]map[string]interface{}) error {
+	for _, kind := range stateKinds {
+		// Plugin metadata has no list endpoint and no timestamps
+		if kind == PluginMetadataKind || len(objects[kind]) == 0 {
+			continue
+		}
+
+		entries, err := c.listResourceEntries(kind)
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", kind, err)
+		}
+
+		for _, entry := range entries {
+			object, exists := objects[kind][entryID(kind, entry)]
+			if !exists {
+				continue
+			}
+
+			raw := entry.Value
+			for _, field := range serverManagedFields {
+				value, set := raw[field]
+				if !set {
+					continue
+				}
+				if seconds, ok := value.(float64); ok && seconds == math.Trunc(seconds) {
+					value = int64(seconds)
+				}
+				object[field] = value
+			}
+		}
+	}
+
+	return nil
+}
+
+// exportNumbers turns json.Number values into int64 or float64, so that YAML writes
+// integers such as timestamps without an exponent
+func exportNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if integer, err := v.Int64(); err == nil {
+			return integer
+		}
+		float, _ := v.Float64()
+		return float
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = exportNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = exportNumbers(item)
+		}
+	}
+
+	return value
+}
+
+// removeRedactedValues deletes every field holding RedactedValue in place
+func removeRedactedValues(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item == RedactedValue {
+				delete(v, key)
+				continue
+			}
+			removeRedactedValues(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			removeRedactedValues(item)
+		}
+	}
+}
+
+func encodeExport(w io.Writer, value interface{}, format ExportFormat) error {
+	if format == ExportJSON {
+		encoded, err := CanonicalJSONIndent(value, "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(append(encoded, '\n'))
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
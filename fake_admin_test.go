@@ -0,0 +1,183 @@
+package api_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAdmin is an in-memory Admin API. Objects are stored by their etcd key, e.g.
+// /apisix/routes/r1, consumer credentials below their consumer.
+type fakeAdmin struct {
+	mu      sync.Mutex
+	objects map[string]map[string]interface{}
+	created int
+	// requests are the method and path of every request received
+	requests []string
+	// fail answers a request with a 500 when it returns true
+	fail func(r *http.Request) bool
+}
+
+// newFakeAdmin starts a fake Admin API and returns it with a client of it
+func newFakeAdmin(t *testing.T) (*fakeAdmin, *ApiClient) {
+	t.Helper()
+
+	admin := &fakeAdmin{objects: map[string]map[string]interface{}{}}
+	server := httptest.NewServer(admin)
+	t.Cleanup(server.Close)
+
+	endpoint, apiKey := server.URL, "test-key"
+	client, err := NewClient(&endpoint, &apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return admin, client
+}
+
+// set stores an object, e.g. set("routes/r1", route)
+func (f *fakeAdmin) set(path string, value map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects["/apisix/"+path] = value
+}
+
+// get returns a stored object, nil when there is none
+func (f *fakeAdmin) get(path string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.objects["/apisix/"+path]
+}
+
+// writes returns the requests received that were not reads
+func (f *fakeAdmin) writes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	writes := []string{}
+	for _, request := range f.requests {
+		if !strings.HasPrefix(request, http.MethodGet) {
+			writes = append(writes, request)
+		}
+	}
+	return writes
+}
+
+func (f *fakeAdmin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	reply := func(status int, body interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+
+	if f.fail != nil && f.fail(r) {
+		reply(http.StatusInternalServerError, map[string]interface{}{"error_msg": "injected failure"})
+		return
+	}
+	if r.Header.Get("X-API-KEY") == "" {
+		reply(http.StatusUnauthorized, map[string]interface{}{"message": "missing apikey"})
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/apisix/admin"), "/")
+	key := "/apisix/" + path
+	segments := strings.Split(path, "/")
+	notFound := map[string]interface{}{"message": "Key not found"}
+
+	switch r.Method {
+	case http.MethodGet:
+		if object, exists := f.objects[key]; exists {
+			reply(http.StatusOK, map[string]interface{}{"key": key, "value": object})
+			return
+		}
+
+		// Lists have one segment, secret lists and credential lists one more
+		isList := len(segments) == 1 || segments[0] == "secrets" || segments[len(segments)-1] == "credentials"
+		if !isList {
+			reply(http.StatusNotFound, notFound)
+			return
+		}
+
+		keys := []string{}
+		for objectKey := range f.objects {
+			rest, found := strings.CutPrefix(objectKey, key+"/")
+			if found && (segments[0] == "secrets" || !strings.Contains(rest, "/")) {
+				keys = append(keys, objectKey)
+			}
+		}
+		sort.Strings(keys)
+
+		list := []interface{}{}
+		for _, objectKey := range keys {
+			list = append(list, map[string]interface{}{"key": objectKey, "value": f.objects[objectKey]})
+		}
+		reply(http.StatusOK, map[string]interface{}{"total": len(list), "list": list})
+
+	case http.MethodPut, http.MethodPost:
+		object := map[string]interface{}{}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &object); err != nil {
+			reply(http.StatusBadRequest, map[string]interface{}{"error_msg": err.Error()})
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodPost:
+			f.created++
+			object["id"] = fmt.Sprintf("generated-%d", f.created)
+			key += "/" + object["id"].(string)
+		case path == "consumers":
+			username, _ := object["username"].(string)
+			key += "/" + username
+		case segments[0] != "consumers" || len(segments) > 2:
+			object["id"] = segments[len(segments)-1]
+		}
+
+		object["create_time"] = 1700000000
+		object["update_time"] = 1700000000
+		f.objects[key] = object
+		reply(http.StatusOK, map[string]interface{}{"key": key, "value": object})
+
+	case http.MethodPatch:
+		object, exists := f.objects[key]
+		if !exists {
+			reply(http.StatusNotFound, notFound)
+			return
+		}
+
+		patch := map[string]interface{}{}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &patch); err != nil {
+			reply(http.StatusBadRequest, map[string]interface{}{"error_msg": err.Error()})
+			return
+		}
+		for field, value := range patch {
+			object[field] = value
+		}
+		reply(http.StatusOK, map[string]interface{}{"key": key, "value": object})
+
+	case http.MethodDelete:
+		if _, exists := f.objects[key]; !exists {
+			reply(http.StatusNotFound, notFound)
+			return
+		}
+		// Like etcd, deleting a consumer deletes its credentials
+		for objectKey := range f.objects {
+			if objectKey == key || strings.HasPrefix(objectKey, key+"/") {
+				delete(f.objects, objectKey)
+			}
+		}
+		reply(http.StatusOK, map[string]interface{}{"key": key, "deleted": "1"})
+	}
+}
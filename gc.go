@@ -0,0 +1,319 @@
+package api_client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultOwnerLabel is the label marking the objects a tool manages, e.g. managed-by: ci
+const DefaultOwnerLabel = "managed-by"
+
+// DefaultGCMaxDeletes is the number of orphans PruneOrphans deletes without confirmation
+const DefaultGCMaxDeletes = 10
+
+type GCOptions struct {
+	// OwnerLabel defaults to DefaultOwnerLabel
+	OwnerLabel string
+	// Owner is the required value of the owner label. Orphans without it are reported as
+	// unmanaged and never deleted.
+	Owner  string
+	DryRun bool
+	// MaxDeletes is the confirmation threshold: pruning more orphans fails unless Confirm
+	// is set. Zero means DefaultGCMaxDeletes.
+	MaxDeletes int
+	Confirm    bool
+}
+
+// Orphan is an object nothing uses
+type Orphan struct {
+	ResourceRef
+	Reason string `json:"reason"`
+}
+
+type GCReport struct {
+	DryRun bool `json:"dry_run"`
+	// Orphans are owned orphans, the ones PruneOrphans deletes
+	Orphans []Orphan `json:"orphans"`
+	// Unmanaged are orphans without the owner label
+	Unmanaged []Orphan      `json:"unmanaged"`
+	Deleted   []ResourceRef `json:"deleted"`
+	// Skipped are orphans PruneOrphans kept because something references them by the time
+	// they are deleted
+	Skipped []Orphan `json:"skipped"`
+}
+
+// FindOrphans - Reports upstreams no route, service, stream route or plugin uses, plugin configs
+// no route references, consumer groups without consumers and server certificates whose
+// SNIs match no route host or stream route SNI.
+//
+// A route without hosts, on its own or through its service, matches every SNI, so no
+// certificate is reported while such a route exists.
+func (c *ApiClient) FindOrphans(options GCOptions) (*GCReport, error) {
+	if options.Owner == "" {
+		return nil, errors.New("the owner of the objects to collect is not provided")
+	}
+
+	ownerLabel := options.OwnerLabel
+	if ownerLabel == "" {
+		ownerLabel = DefaultOwnerLabel
+	}
+
+	index, err := c.BuildReferenceIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GCReport{DryRun: options.DryRun, Orphans: []Orphan{}, Unmanaged: []Orphan{}, Deleted: []ResourceRef{}, Skipped: []Orphan{}}
+	add := func(kind ResourceKind, resource map[string]interface{}, reason string) {
+		orphan := Orphan{ResourceRef: ResourceRef{Kind: kind, ID: resourceID(kind, resource)}, Reason: reason}
+		if labelsMatch(resource, map[string]string{ownerLabel: options.Owner}) {
+			report.Orphans = append(report.Orphans, orphan)
+		} else {
+			report.Unmanaged = append(report.Unmanaged, orphan)
+		}
+	}
+
+	pluginUpstreams, err := c.pluginUpstreamIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	unreferenced := []struct {
+		kind   ResourceKind
+		reason string
+	}{
+		{UpstreamKind, "no route, service or stream route uses it"},
+		{PluginConfigKind, "no route references it"},
+		{ConsumerGroupKind, "no consumer belongs to it"},
+	}
+	for _, candidate := range unreferenced {
+		resources, err := c.listResources(candidate.kind)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", candidate.kind, err)
+		}
+
+		for _, resource := range resources {
+			id := resourceID(candidate.kind, resource)
+			if len(index.Referrers(candidate.kind, id)) > 0 {
+				continue
+			}
+			if candidate.kind == UpstreamKind && pluginUpstreams[id] {
+				continue
+			}
+			add(candidate.kind, resource, candidate.reason)
+		}
+	}
+
+	hosts, matchesAll, err := c.routeHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	if !matchesAll {
+		certificates, err := c.listResources(SSLKind)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", SSLKind, err)
+		}
+
+		for _, certificate := range certificates {
+			id := resourceID(SSLKind, certificate)
+			if certificateType, _ := certificate["type"].(string); certificateType == "client" {
+				continue
+			}
+			if len(index.Referrers(SSLKind, id)) > 0 {
+				continue
+			}
+
+			if !snisMatchHosts(stringList(certificate["snis"], certificate["sni"]), hosts) {
+				add(SSLKind, certificate, "no route host matches its SNIs")
+			}
+		}
+	}
+
+	sortOrphans(report.Orphans)
+	sortOrphans(report.Unmanaged)
+	return report, nil
+}
+
+// PruneOrphans - Deletes the owned orphans FindOrphans reports. A dry run only reports;
+// otherwise more deletions than MaxDeletes need Confirm. Every orphan is deleted with
+// SafeDelete, so one referenced since FindOrphans ran is skipped instead.
+func (c *ApiClient) PruneOrphans(options GCOptions) (*GCReport, error) {
+	report, err := c.FindOrphans(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.DryRun {
+		return report, nil
+	}
+
+	maxDeletes := options.MaxDeletes
+	if maxDeletes == 0 {
+		maxDeletes = DefaultGCMaxDeletes
+	}
+	if len(report.Orphans) > maxDeletes && !options.Confirm {
+		return report, fmt.Errorf("refusing to delete %d orphans, more than %d, without confirmation", len(report.Orphans), maxDeletes)
+	}
+
+	for _, orphan := range report.Orphans {
+		_, err := c.SafeDelete(orphan.Kind, orphan.ID, SafeDeleteOptions{})
+		var inUse *ResourceInUseError
+		switch {
+		case errors.As(err, &inUse):
+			report.Skipped = append(report.Skipped, orphan)
+			continue
+		case IsNotFound(err):
+			// Deleted by someone else in the meantime
+			continue
+		case err != nil:
+			return report, fmt.Errorf("failed to delete %s: %w", orphan.ResourceRef, err)
+		}
+		report.Deleted = append(report.Deleted, orphan.ResourceRef)
+	}
+
+	return report, nil
+}
+
+// pluginUpstreamIDs returns every upstream_id held at any depth of any plugin config, so
+// upstreams used by plugins such as traffic-split are never collected, even by plugins the
+// reference index does not know
+func (c *ApiClient) pluginUpstreamIDs() (map[string]bool, error) {
+	ids := map[string]bool{}
+	for _, kind := range []ResourceKind{RouteKind, ServiceKind, PluginConfigKind, ConsumerGroupKind, ConsumerKind, StreamRouteKind, GlobalRuleKind} {
+		resources, err := c.listResources(kind)
+		if kind == StreamRouteKind && isStreamModeDisabled(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", kind, err)
+		}
+
+		for _, resource := range resources {
+			collectUpstreamIDs(resource["plugins"], ids)
+		}
+	}
+
+	return ids, nil
+}
+
+func collectUpstreamIDs(value interface{}, ids map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if id, ok := item.(string); ok && key == "upstream_id" {
+				ids[id] = true
+				continue
+			}
+			collectUpstreamIDs(item, ids)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectUpstreamIDs(item, ids)
+		}
+	}
+}
+
+// routeHosts returns the hosts of all routes, including those inherited from their
+// service, and the SNIs of stream routes. It also reports whether a route without hosts
+// matches any host.
+func (c *ApiClient) routeHosts() ([]string, bool, error) {
+	services, err := c.listResources(ServiceKind)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list %s: %w", ServiceKind, err)
+	}
+
+	serviceHosts := map[string][]string{}
+	for _, service := range services {
+		serviceHosts[resourceID(ServiceKind, service)] = stringList(service["hosts"])
+	}
+
+	routes, err := c.listResources(RouteKind)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list %s: %w", RouteKind, err)
+	}
+
+	hosts := []string{}
+	for _, route := range routes {
+		routeHosts := stringList(route["hosts"], route["host"])
+		if len(routeHosts) == 0 {
+			serviceID, _ := route["service_id"].(string)
+			routeHosts = serviceHosts[serviceID]
+		}
+
+		if len(routeHosts) == 0 {
+			return nil, true, nil
+		}
+		hosts = append(hosts, routeHosts...)
+	}
+
+	// Stream routes terminating TLS select their certificate by SNI
+	streamRoutes, err := c.listResources(StreamRouteKind)
+	if err != nil && !isStreamModeDisabled(err) {
+		return nil, false, fmt.Errorf("failed to list %s: %w", StreamRouteKind, err)
+	}
+	for _, streamRoute := range streamRoutes {
+		hosts = append(hosts, stringList(streamRoute["sni"])...)
+	}
+
+	return hosts, false, nil
+}
+
+// snisMatchHosts reports whether any SNI matches any host. Both may be wildcards such as
+// *.example.com, which match a single label.
+func snisMatchHosts(snis, hosts []string) bool {
+	for _, sni := range snis {
+		for _, host := range hosts {
+			if hostPatternMatches(sni, host) || hostPatternMatches(host, sni) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hostPatternMatches(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	if pattern == host {
+		return true
+	}
+
+	suffix, isWildcard := strings.CutPrefix(pattern, "*.")
+	if !isWildcard {
+		return false
+	}
+
+	label, rest, found := strings.Cut(host, ".")
+	return found && label != "" && rest == suffix
+}
+
+// stringList collects the strings of generic string and string array values
+func stringList(values ...interface{}) []string {
+	list := []string{}
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			list = append(list, v)
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					list = append(list, s)
+				}
+			}
+		}
+	}
+
+	return list
+}
+
+func sortOrphans(orphans []Orphan) {
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].Kind != orphans[j].Kind {
+			return orphans[i].Kind < orphans[j].Kind
+		}
+		return orphans[i].ID < orphans[j].ID
+	})
+}
@@ -0,0 +1,119 @@
+package api_client
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCollectUpstreamIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugins string
+		want    map[string]bool
+	}{
+		{
+			name:    "no plugins",
+			plugins: `null`,
+			want:    map[string]bool{},
+		},
+		{
+			name:    "traffic-split",
+			plugins: `{"traffic-split":{"rules":[{"weighted_upstreams":[{"upstream_id":"u1","weight":1},{"weight":1}]}]}}`,
+			want:    map[string]bool{"u1": true},
+		},
+		{
+			name:    "any plugin at any depth",
+			plugins: `{"a":{"upstream_id":"u1"},"b":{"targets":[{"backend":{"upstream_id":"u2"}}]}}`,
+			want:    map[string]bool{"u1": true, "u2": true},
+		},
+		{
+			name:    "non-string upstream_id",
+			plugins: `{"a":{"upstream_id":{"upstream_id":"u3"}}}`,
+			want:    map[string]bool{"u3": true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var plugins interface{}
+			if err := json.Unmarshal([]byte(test.plugins), &plugins); err != nil {
+				t.Fatal(err)
+			}
+
+			ids := map[string]bool{}
+			collectUpstreamIDs(plugins, ids)
+			if !reflect.DeepEqual(ids, test.want) {
+				t.Errorf("collectUpstreamIDs() = %v, want %v", ids, test.want)
+			}
+		})
+	}
+}
+
+// setupOrphans stores owned upstreams nothing uses
+func setupOrphans(admin *fakeAdmin, ids ...string) {
+	for _, id := range ids {
+		admin.set("upstreams/"+id, map[string]interface{}{
+			"id": id, "type": "roundrobin", "nodes": map[string]interface{}{"10.0.0.1:80": 1},
+			"labels": map[string]interface{}{"managed-by": "ci"},
+		})
+	}
+}
+
+func TestPruneOrphansThreshold(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+	setupOrphans(admin, "u1", "u2")
+
+	report, err := client.PruneOrphans(GCOptions{Owner: "ci", MaxDeletes: 1, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run error = %v, want the orphans reported", err)
+	}
+	if len(report.Orphans) != 2 || len(report.Deleted) != 0 {
+		t.Errorf("dry run report %+v, want two orphans and no deletions", report)
+	}
+
+	if _, err := client.PruneOrphans(GCOptions{Owner: "ci", MaxDeletes: 1}); err == nil || !strings.Contains(err.Error(), "without confirmation") {
+		t.Errorf("error = %v, want the confirmation threshold", err)
+	}
+	if writes := admin.writes(); len(writes) != 0 {
+		t.Errorf("gateway received writes %v", writes)
+	}
+
+	report, err = client.PruneOrphans(GCOptions{Owner: "ci", MaxDeletes: 1, Confirm: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Deleted) != 2 {
+		t.Errorf("deleted %v, want both orphans", report.Deleted)
+	}
+}
+
+func TestPruneOrphansRechecksReferrers(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+	setupOrphans(admin, "u1", "u2")
+
+	// A route starts using u2 while u1 is being deleted
+	admin.fail = func(r *http.Request) bool {
+		if r.Method == http.MethodDelete && r.URL.Path == "/apisix/admin/upstreams/u1" {
+			admin.objects["/apisix/routes/late"] = map[string]interface{}{"id": "late", "uri": "/late/*", "upstream_id": "u2"}
+		}
+		return false
+	}
+
+	report, err := client.PruneOrphans(GCOptions{Owner: "ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []ResourceRef{{Kind: UpstreamKind, ID: "u1"}}; !reflect.DeepEqual(report.Deleted, want) {
+		t.Errorf("deleted %v, want %v", report.Deleted, want)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].ID != "u2" {
+		t.Errorf("skipped %v, want u2", report.Skipped)
+	}
+	if admin.get("upstreams/u2") == nil {
+		t.Error("upstream u2 was deleted while a route uses it")
+	}
+}
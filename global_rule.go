@@ -18,6 +18,11 @@ type GlobalRuleResponse struct {
 	Value GlobalRule `json:"value"`
 }
 
+type GlobalRuleListResponse struct {
+	Total int                  `json:"total"`
+	List  []GlobalRuleResponse `json:"list"`
+}
+
 // GetGlobalRule - Returns a specific global rule
 func (c *ApiClient) GetGlobalRule(ruleID string) (*GlobalRule, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/global_rules/%s", c.Endpoint, ruleID), nil)
@@ -39,6 +44,32 @@ func (c *ApiClient) GetGlobalRule(ruleID string) (*GlobalRule, error) {
 	return &getResponse.Value, nil
 }
 
+// ListGlobalRules - Returns all global rules
+func (c *ApiClient) ListGlobalRules() ([]GlobalRule, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/global_rules", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := GlobalRuleListResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]GlobalRule, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		rules = append(rules, item.Value)
+	}
+
+	return rules, nil
+}
+
 // CreateGlobalRule - Creates a new global rule
 func (c *ApiClient) CreateGlobalRule(ruleID string, rule GlobalRule) (*GlobalRule, error) {
 	rb, err := json.Marshal(rule)
@@ -0,0 +1,264 @@
+package api_client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LocalCA is a throwaway certificate authority for test gateways that do not have
+// access to a real CA
+type LocalCA struct {
+	Certificate *x509.Certificate
+	PrivateKey  crypto.Signer
+	CertPEM     string
+	KeyPEM      string
+}
+
+type IssuedCertificate struct {
+	Certificate *x509.Certificate
+	CertPEM     string
+	KeyPEM      string
+}
+
+// NewLocalCA - Generates a self-signed CA valid for the given duration
+func NewLocalCA(commonName string, validity time.Duration) (*LocalCA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := certificateTemplate(commonName, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalCA{
+		Certificate: certificate,
+		PrivateKey:  key,
+		CertPEM:     encodeCertificate(der),
+		KeyPEM:      keyPEM,
+	}, nil
+}
+
+// LoadLocalCA - Loads a CA previously exported with WriteFiles or CertPEM/KeyPEM
+func LoadLocalCA(certPEM, keyPEM string) (*LocalCA, error) {
+	leaf, err := parseCertificateKeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	if !leaf.IsCA {
+		return nil, errors.New("certificate is not a CA")
+	}
+
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key cannot be used for signing")
+	}
+
+	return &LocalCA{
+		Certificate: leaf,
+		PrivateKey:  signer,
+		CertPEM:     certPEM,
+		KeyPEM:      keyPEM,
+	}, nil
+}
+
+// IssueServerCertificate - Issues a leaf certificate for a set of SNIs. IP addresses
+// are added as IP SANs, everything else as DNS names. TLS clients never send an IP
+// address as SNI, so only the DNS names are served when the certificate is uploaded.
+func (ca *LocalCA) IssueServerCertificate(snis []string, validity time.Duration) (*IssuedCertificate, error) {
+	if len(snis) == 0 {
+		return nil, errors.New("at least one sni is required")
+	}
+
+	template, err := certificateTemplate(snis[0], validity)
+	if err != nil {
+		return nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	for _, sni := range snis {
+		if ip := net.ParseIP(sni); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, sni)
+		}
+	}
+
+	return ca.issue(template)
+}
+
+// IssueClientCertificate - Issues a certificate for TLS client authentication
+func (ca *LocalCA) IssueClientCertificate(commonName string, validity time.Duration) (*IssuedCertificate, error) {
+	template, err := certificateTemplate(commonName, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	return ca.issue(template)
+}
+
+// CertPool - Returns a pool trusting the CA, for use in test clients
+func (ca *LocalCA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Certificate)
+	return pool
+}
+
+// WriteFiles - Exports the CA certificate and key as ca.crt and ca.key into a directory
+func (ca *LocalCA) WriteFiles(dir string) error {
+	if err := os.WriteFile(filepath.Join(dir, "ca.crt"), []byte(ca.CertPEM), 0o644); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, "ca.key"), []byte(ca.KeyPEM), 0o600)
+}
+
+func (ca *LocalCA) issue(template *x509.Certificate) (*IssuedCertificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if template.NotAfter.After(ca.Certificate.NotAfter) {
+		template.NotAfter = ca.Certificate.NotAfter
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, key.Public(), ca.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IssuedCertificate{
+		Certificate: certificate,
+		CertPEM:     encodeCertificate(der),
+		KeyPEM:      keyPEM,
+	}, nil
+}
+
+// UploadServerCertificate - Uploads an issued certificate as a server SSL object for its
+// DNS names. IP SANs are not valid SNIs and are left out.
+func (c *ApiClient) UploadServerCertificate(issued *IssuedCertificate) (*SSLCertificate, error) {
+	snis := append([]string{}, issued.Certificate.DNSNames...)
+	if len(snis) == 0 {
+		return nil, errors.New("certificate has no DNS names to serve as snis")
+	}
+
+	status := SSLCertificateStatusEnabled
+	certificateType := "server"
+	return c.CreateSslCertificate(SSLCertificate{
+		Status:      &status,
+		Certificate: &issued.CertPEM,
+		PrivateKey:  &issued.KeyPEM,
+		SNIs:        &snis,
+		Type:        &certificateType,
+	})
+}
+
+// UploadClientCertificate - Uploads an issued certificate as a client SSL object and
+// returns the upstream TLS settings referencing it
+func (c *ApiClient) UploadClientCertificate(issued *IssuedCertificate) (*SSLCertificate, *UpstreamTLSType, error) {
+	status := SSLCertificateStatusEnabled
+	certificateType := "client"
+	created, err := c.CreateSslCertificate(SSLCertificate{
+		Status:      &status,
+		Certificate: &issued.CertPEM,
+		PrivateKey:  &issued.KeyPEM,
+		Type:        &certificateType,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if created.ID == nil {
+		return created, nil, errors.New("no id returned for client certificate")
+	}
+
+	return created, &UpstreamTLSType{ClientCertID: created.ID}, nil
+}
+
+func certificateTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	if validity <= 0 {
+		return nil, fmt.Errorf("invalid validity: %s", validity)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+func encodeCertificate(der []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func encodePrivateKey(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
@@ -0,0 +1,44 @@
+package api_client
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUploadServerCertificate(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+
+	ca, err := NewLocalCA("test ca", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issued, err := ca.IssueServerCertificate([]string{"example.com", "127.0.0.1", "*.example.com"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issued.Certificate.IPAddresses) != 1 {
+		t.Errorf("IP SANs %v, want 127.0.0.1", issued.Certificate.IPAddresses)
+	}
+
+	created, err := client.UploadServerCertificate(issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com", "*.example.com"}; created.SNIs == nil || !reflect.DeepEqual(*created.SNIs, want) {
+		t.Errorf("snis %v, want %v", created.SNIs, want)
+	}
+
+	ipOnly, err := ca.IssueServerCertificate([]string{"10.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writes := len(admin.writes())
+	if _, err := client.UploadServerCertificate(ipOnly); err == nil {
+		t.Error("UploadServerCertificate() of an IP-only certificate succeeded, want an error")
+	}
+	if len(admin.writes()) != writes {
+		t.Error("IP-only certificate was uploaded")
+	}
+}
@@ -0,0 +1,175 @@
+package api_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type OperationVerb string
+
+const (
+	OperationGet    OperationVerb = "get"
+	OperationList   OperationVerb = "list"
+	OperationCreate OperationVerb = "create"
+	// OperationUpdate replaces a resource, creating it when it does not exist
+	OperationUpdate OperationVerb = "update"
+	OperationPatch  OperationVerb = "patch"
+	OperationDelete OperationVerb = "delete"
+)
+
+// Operation is the logical Admin API call a request makes
+type Operation struct {
+	Kind ResourceKind
+	Verb OperationVerb
+	// ID is empty for lists and for creates where the gateway generates it
+	ID string
+	// SubPath addresses part of the resource, e.g. plugins/limit-count or credentials/cred-1
+	SubPath string
+}
+
+// RequestHandler sends a request of an operation and returns the raw response
+type RequestHandler func(op Operation, req *http.Request) (*http.Response, error)
+
+// Middleware wraps the handler of every request of a client. It may change the request,
+// inspect or replace the response, or fail without calling next.
+type Middleware func(next RequestHandler) RequestHandler
+
+// APIKeyMiddleware - Returns the middleware authenticating requests with an Admin API key.
+// NewClient installs it as the first middleware.
+func APIKeyMiddleware(apiKey string) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(op Operation, req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-API-KEY", apiKey)
+			return next(op, req)
+		}
+	}
+}
+
+// HeadersMiddleware - Returns a middleware adding headers to every request, e.g. a tenant header
+func HeadersMiddleware(headers http.Header) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(op Operation, req *http.Request) (*http.Response, error) {
+			for k, vs := range headers {
+				for _, v := range vs {
+					req.Header.Add(k, v)
+				}
+			}
+			return next(op, req)
+		}
+	}
+}
+
+// Use - Appends middlewares to the chain of the client. The first middleware is the
+// outermost one: it sees the request first and the response last.
+func (c *ApiClient) Use(middlewares ...Middleware) {
+	// Copy so clients returned by WithContext do not share the chain
+	c.Middlewares = append(c.Middlewares[:len(c.Middlewares):len(c.Middlewares)], middlewares...)
+}
+
+// handler returns the middleware chain of the client around the transport
+func (c *ApiClient) handler() RequestHandler {
+	handler := c.roundTrip
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		handler = c.Middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// roundTrip sends a request, or records it when the client is in dry-run mode
+func (c *ApiClient) roundTrip(op Operation, req *http.Request) (*http.Response, error) {
+	if c.dryRun == nil {
+		return c.HTTPClient.Do(req)
+	}
+
+	if !isMutatingMethod(req.Method) {
+		status, body, recorded := c.dryRunRead(req)
+		if !recorded {
+			return c.HTTPClient.Do(req)
+		}
+		return dryRunResponse(req, status, body), nil
+	}
+
+	body, err := c.dryRunRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return dryRunResponse(req, http.StatusOK, body), nil
+}
+
+// dryRunResponse wraps the body a dry-run client answers with in an HTTP response
+func dryRunResponse(req *http.Request, status int, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// operationOf returns the logical operation of an Admin API request
+func operationOf(req *http.Request) (Operation, error) {
+	kind, id, subPath, err := parseAdminPath(req.URL.Path)
+	if err != nil {
+		// Requests outside the Admin API have no operation
+		return Operation{}, nil
+	}
+
+	op := Operation{Kind: kind, ID: id, SubPath: subPath}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		op.Verb = OperationGet
+		if id == "" {
+			op.Verb = OperationList
+		}
+	case http.MethodPost:
+		op.Verb = OperationCreate
+	case http.MethodPatch:
+		op.Verb = OperationPatch
+	case http.MethodDelete:
+		op.Verb = OperationDelete
+	default:
+		op.Verb = OperationUpdate
+	}
+
+	if kind == ConsumerKind && id == "" && req.Method == http.MethodPut {
+		op.ID, err = consumerUsernameOf(req)
+		if err != nil {
+			return op, err
+		}
+	}
+
+	return op, nil
+}
+
+// consumerUsernameOf reads the username of a consumer write, which identifies it in the
+// body instead of the path, and leaves the body readable
+func consumerUsernameOf(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	consumer := map[string]interface{}{}
+	if json.Unmarshal(body, &consumer) != nil {
+		return "", nil
+	}
+
+	username, _ := consumer["username"].(string)
+	return username, nil
+}
@@ -0,0 +1,130 @@
+package api_client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// tracingMiddleware records when a request enters and leaves it
+func tracingMiddleware(name string, trace *[]string) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(op Operation, req *http.Request) (*http.Response, error) {
+			*trace = append(*trace, name+" "+string(op.Verb)+" "+string(op.Kind)+"/"+op.ID)
+			res, err := next(op, req)
+			*trace = append(*trace, name+" done")
+			return res, err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+	admin.set("routes/api", map[string]interface{}{"id": "api", "uri": "/api/*"})
+
+	trace := []string{}
+	client.Use(tracingMiddleware("outer", &trace), tracingMiddleware("inner", &trace))
+
+	if _, err := client.GetRoute("api"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"outer get routes/api", "inner get routes/api", "inner done", "outer done"}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("trace %v, want %v", trace, want)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+	admin.set("routes/api", map[string]interface{}{"id": "api", "uri": "/api/*"})
+
+	errReadOnly := errors.New("read-only client")
+	client.Use(func(next RequestHandler) RequestHandler {
+		return func(op Operation, req *http.Request) (*http.Response, error) {
+			switch op.Verb {
+			case OperationDelete:
+				return nil, errReadOnly
+			case OperationGet:
+				// Answer from a cache without calling the gateway
+				body := []byte(`{"key":"/apisix/routes/api","value":{"id":"api","uri":"/cached/*"}}`)
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body)), Request: req}, nil
+			}
+			return next(op, req)
+		}
+	})
+
+	if err := client.DeleteRoute("api"); !errors.Is(err, errReadOnly) {
+		t.Errorf("DeleteRoute() error = %v, want the middleware error", err)
+	}
+
+	route, err := client.GetRoute("api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *route.URI != "/cached/*" {
+		t.Errorf("route uri = %s, want the response of the middleware", *route.URI)
+	}
+
+	if len(admin.requests) != 0 {
+		t.Errorf("gateway received %v, want no requests", admin.requests)
+	}
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	defaultTransport := http.DefaultClient.Transport
+	admin, client := newFakeAdmin(t)
+	admin.set("routes/api", map[string]interface{}{"id": "api", "uri": "/api/*"})
+
+	if http.DefaultClient.Transport != defaultTransport {
+		t.Error("NewClient changed http.DefaultClient")
+	}
+	if client.HTTPClient == http.DefaultClient {
+		t.Error("NewClient uses http.DefaultClient")
+	}
+
+	headers := http.Header{}
+	client.Use(HeadersMiddleware(http.Header{"X-Tenant": {"team-a"}}), func(next RequestHandler) RequestHandler {
+		return func(op Operation, req *http.Request) (*http.Response, error) {
+			headers = req.Header.Clone()
+			return next(op, req)
+		}
+	})
+
+	if _, err := client.GetRoute("api"); err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get("X-API-KEY") != "test-key" || headers.Get("X-Tenant") != "team-a" {
+		t.Errorf("request headers %v, want the API key and the tenant", headers)
+	}
+
+	// Without the middleware the gateway rejects the request
+	client.Middlewares = nil
+	var apiErr *APIError
+	if _, err := client.GetRoute("api"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GetRoute() without API key error = %v, want 401", err)
+	}
+}
+
+func TestUseDoesNotShareChain(t *testing.T) {
+	_, client := newFakeAdmin(t)
+
+	trace := []string{}
+	clone := client.WithContext(context.Background())
+	clone.Use(tracingMiddleware("clone", &trace))
+	client.Use(tracingMiddleware("original", &trace))
+
+	if len(client.Middlewares) != 2 || len(clone.Middlewares) != 2 {
+		t.Fatalf("middlewares %d and %d, want 2 each", len(client.Middlewares), len(clone.Middlewares))
+	}
+	if _, err := clone.ListRoutes(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"clone list routes/", "clone done"}; !reflect.DeepEqual(trace, want) {
+		t.Errorf("trace %v, want %v", trace, want)
+	}
+}
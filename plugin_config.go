@@ -20,6 +20,11 @@ type PluginConfigAPIResponse struct {
 	Value PluginConfig `json:"value"`
 }
 
+type PluginConfigListAPIResponse struct {
+	Total int                       `json:"total"`
+	List  []PluginConfigAPIResponse `json:"list"`
+}
+
 // GetPluginConfig - Returns a plugin config
 func (c *ApiClient) GetPluginConfig(configID string) (*PluginConfig, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/plugin_configs/%s", c.Endpoint, configID), nil)
@@ -41,6 +46,32 @@ func (c *ApiClient) GetPluginConfig(configID string) (*PluginConfig, error) {
 	return &getResponse.Value, nil
 }
 
+// ListPluginConfigs - Returns all plugin configs
+func (c *ApiClient) ListPluginConfigs() ([]PluginConfig, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/plugin_configs", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := PluginConfigListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]PluginConfig, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		configs = append(configs, item.Value)
+	}
+
+	return configs, nil
+}
+
 // CreatePluginConfig - Creates a new plugin config
 func (c *ApiClient) CreatePluginConfig(configID string, config PluginConfig) (*PluginConfig, error) {
 	rb, err := json.Marshal(config)
@@ -0,0 +1,217 @@
+package api_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PluginMeta is the _meta block of a plugin config, which controls how the plugin runs
+type PluginMeta struct {
+	// Priority overrides the execution priority of the plugin
+	Priority *int64 `json:"priority,omitempty"`
+	// Filter only runs the plugin when the request matches the lua-resty-expr expression,
+	// e.g. [["arg_version", "==", "v2"]]
+	Filter *[]interface{} `json:"filter,omitempty"`
+	// ErrorResponse replaces the body of responses rejected by the plugin, either a string or an object
+	ErrorResponse interface{} `json:"error_response,omitempty"`
+	Disable       *bool       `json:"disable,omitempty"`
+}
+
+// filterOperators are the lua-resty-expr comparison operators
+var filterOperators = map[string]bool{
+	"==": true, "~=": true, ">": true, ">=": true, "<": true, "<=": true,
+	"~~": true, "~*": true, "in": true, "has": true, "!": true, "ipmatch": true,
+}
+
+// filterLogicalOperators combine nested lua-resty-expr expressions
+var filterLogicalOperators = map[string]bool{
+	"AND": true, "OR": true, "!AND": true, "!OR": true,
+}
+
+// Validate - Checks the filter expression and error response of the meta
+func (m PluginMeta) Validate() error {
+	if m.Filter != nil {
+		if err := ValidateFilterExpression(*m.Filter); err != nil {
+			return err
+		}
+	}
+
+	switch m.ErrorResponse.(type) {
+	case nil, string, map[string]interface{}:
+	default:
+		return fmt.Errorf("error_response must be a string or an object, got %T", m.ErrorResponse)
+	}
+
+	return nil
+}
+
+// ValidateFilterExpression - Checks the syntax of a lua-resty-expr expression as used by
+// _meta.filter and route vars: a list of [var, operator, value] rules, optionally
+// negated as [var, "!", operator, value], or a logical operator ("AND", "OR", "!AND",
+// "!OR") followed by nested expressions
+func ValidateFilterExpression(expression []interface{}) error {
+	return validateFilterExpression(expression, "filter")
+}
+
+func validateFilterExpression(expression []interface{}, path string) error {
+	if len(expression) == 0 {
+		return nil
+	}
+
+	items := expression
+	if operator, ok := expression[0].(string); ok {
+		if !filterLogicalOperators[operator] {
+			return fmt.Errorf("%s: expected a list of rules or a logical operator, got %q", path, operator)
+		}
+		if len(expression) < 2 {
+			return fmt.Errorf("%s: %s needs at least one expression", path, operator)
+		}
+		items = expression[1:]
+	}
+
+	for i, item := range items {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if len(items) != len(expression) {
+			itemPath = fmt.Sprintf("%s[%d]", path, i+1)
+		}
+
+		list, ok := item.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a rule or expression, got %T", itemPath, item)
+		}
+
+		if isFilterRule(list) {
+			if err := validateFilterRule(list, itemPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := validateFilterExpression(list, itemPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isFilterRule reports whether a list is a rule rather than a nested expression
+func isFilterRule(list []interface{}) bool {
+	if len(list) == 0 {
+		return false
+	}
+
+	first, ok := list[0].(string)
+	return ok && !filterLogicalOperators[first]
+}
+
+func validateFilterRule(rule []interface{}, path string) error {
+	if len(rule) != 3 && len(rule) != 4 {
+		return fmt.Errorf("%s: a rule has 3 or 4 items, got %d", path, len(rule))
+	}
+
+	operatorIndex := 1
+	if len(rule) == 4 {
+		if negation, _ := rule[1].(string); negation != "!" {
+			return fmt.Errorf("%s: a rule with 4 items must be negated with \"!\"", path)
+		}
+		operatorIndex = 2
+	}
+
+	operator, ok := rule[operatorIndex].(string)
+	if !ok || !filterOperators[operator] {
+		return fmt.Errorf("%s: unknown operator %v", path, rule[operatorIndex])
+	}
+
+	value := rule[operatorIndex+1]
+	switch operator {
+	case "in":
+		if _, ok := value.([]interface{}); !ok {
+			return fmt.Errorf("%s: the in operator needs a list of values", path)
+		}
+	case "~~", "~*":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: the %s operator needs a regular expression string", path, operator)
+		}
+	case "ipmatch":
+		switch addresses := value.(type) {
+		case string:
+		case []interface{}:
+			for _, address := range addresses {
+				if _, ok := address.(string); !ok {
+					return fmt.Errorf("%s: the ipmatch operator needs IP addresses or CIDRs", path)
+				}
+			}
+		default:
+			return fmt.Errorf("%s: the ipmatch operator needs IP addresses or CIDRs", path)
+		}
+	}
+
+	return nil
+}
+
+// AttachPluginMeta - Validates the meta and sets it as _meta of a plugin config. An empty
+// meta removes the _meta block.
+func AttachPluginMeta(conf map[string]interface{}, meta PluginMeta) error {
+	if conf == nil {
+		return errors.New("plugin config is nil")
+	}
+
+	if err := meta.Validate(); err != nil {
+		return err
+	}
+
+	value, err := toJSONValue(meta)
+	if err != nil {
+		return err
+	}
+
+	if fields, _ := value.(map[string]interface{}); len(fields) == 0 {
+		delete(conf, "_meta")
+		return nil
+	}
+
+	conf["_meta"] = value
+	return nil
+}
+
+// PluginMetaFromConfig - Reads the _meta block of a plugin config, nil when there is none
+func PluginMetaFromConfig(conf interface{}) (*PluginMeta, error) {
+	confMap, ok := conf.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("plugin config must be an object, got %T", conf)
+	}
+
+	value, exists := confMap["_meta"]
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	rb, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := PluginMeta{}
+	if err := json.Unmarshal(rb, &meta); err != nil {
+		return nil, fmt.Errorf("invalid _meta: %w", err)
+	}
+
+	return &meta, nil
+}
+
+// PluginMetaOf - Reads the _meta block of a plugin in a plugins map such as Route.Plugins
+// or Service.Plugins, nil when the plugin has none
+func PluginMetaOf(plugins *map[string]interface{}, pluginName string) (*PluginMeta, error) {
+	if plugins == nil {
+		return nil, fmt.Errorf("plugin %s is not configured", pluginName)
+	}
+
+	conf, exists := (*plugins)[pluginName]
+	if !exists {
+		return nil, fmt.Errorf("plugin %s is not configured", pluginName)
+	}
+
+	return PluginMetaFromConfig(conf)
+}
@@ -0,0 +1,57 @@
+package api_client
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateFilterExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{name: "empty", expression: `[]`},
+		{name: "rule", expression: `[["arg_version", "==", "v2"]]`},
+		{name: "rules", expression: `[["arg_version", "==", "v2"], ["http_x_env", "~=", "prod"]]`},
+		{name: "negated rule", expression: `[["arg_version", "!", "==", "v2"]]`},
+		{name: "in", expression: `[["arg_version", "in", ["v1", "v2"]]]`},
+		{name: "regex", expression: `[["uri", "~~", "^/api"]]`},
+		{name: "ipmatch address", expression: `[["remote_addr", "ipmatch", "10.0.0.0/8"]]`},
+		{name: "ipmatch addresses", expression: `[["remote_addr", "ipmatch", ["10.0.0.1", "192.168.0.0/16"]]]`},
+		{name: "logical operator", expression: `["OR", ["arg_a", "==", "1"], ["arg_b", "==", "2"]]`},
+		{name: "nested logical operators", expression: `["!AND", ["arg_a", "==", "1"], ["OR", ["arg_b", "==", "2"], ["arg_c", ">", 3]]]`},
+		{name: "nested expression", expression: `[[["arg_a", "==", "1"]]]`},
+		{name: "unknown logical operator", expression: `["XOR", ["arg_a", "==", "1"]]`, wantErr: `filter: expected a list of rules or a logical operator, got "XOR"`},
+		{name: "logical operator alone", expression: `["AND"]`, wantErr: "filter: AND needs at least one expression"},
+		{name: "not a rule", expression: `["OR", "arg_a"]`, wantErr: "filter[1]: expected a rule or expression, got string"},
+		{name: "short rule", expression: `[["arg_a", "=="]]`, wantErr: "filter[0]: a rule has 3 or 4 items, got 2"},
+		{name: "bad negation", expression: `[["arg_a", "not", "==", "1"]]`, wantErr: `filter[0]: a rule with 4 items must be negated with "!"`},
+		{name: "unknown operator", expression: `[["arg_a", "=", "1"]]`, wantErr: "filter[0]: unknown operator ="},
+		{name: "in without list", expression: `[["arg_a", "in", "v1"]]`, wantErr: "filter[0]: the in operator needs a list of values"},
+		{name: "regex without string", expression: `[["uri", "~*", 1]]`, wantErr: "filter[0]: the ~* operator needs a regular expression string"},
+		{name: "ipmatch without addresses", expression: `[["remote_addr", "ipmatch", [1]]]`, wantErr: "filter[0]: the ipmatch operator needs IP addresses or CIDRs"},
+		{name: "error in nested expression", expression: `["AND", ["arg_a", "==", "1"], ["OR", ["arg_b", "=", "2"]]]`, wantErr: "filter[2][1]: unknown operator ="},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var expression []interface{}
+			if err := json.Unmarshal([]byte(test.expression), &expression); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateFilterExpression(expression)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateFilterExpression() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ValidateFilterExpression() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"sort"
 	"strings"
 )
 
@@ -19,68 +18,24 @@ type PluginMetadataAPIResponse struct {
 	Value PluginMetadata `json:"value"`
 }
 
-// normalizeJSON ensures consistent JSON key ordering
-func normalizeJSON(data map[string]interface{}) map[string]interface{} {
-	normalized := make(map[string]interface{})
-	keys := make([]string, 0, len(data))
-	for k := range data {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	for _, key := range keys {
-		value := data[key]
-		switch v := value.(type) {
-		case map[string]interface{}:
-			normalized[key] = normalizeJSON(v)
-		case []interface{}:
-			normalizedArray := make([]interface{}, len(v))
-			for i, item := range v {
-				if itemMap, ok := item.(map[string]interface{}); ok {
-					normalizedArray[i] = normalizeJSON(itemMap)
-				} else {
-					normalizedArray[i] = item
-				}
-			}
-			normalized[key] = normalizedArray
-		default:
-			normalized[key] = value
-		}
-	}
-	return normalized
-}
-
 // MarshalJSON implements custom JSON marshaling - passes user input directly
 func (pm PluginMetadata) MarshalJSON() ([]byte, error) {
 	result := make(map[string]interface{})
 
-	//fmt.Printf("DEBUG MarshalJSON: pm.Id = %v\n", pm.Id)
-	//fmt.Printf("DEBUG MarshalJSON: pm.Metadata = %v\n", pm.Metadata)
-
 	// Add ID if present
 	if pm.Id != nil {
 		result["id"] = *pm.Id
-		//fmt.Printf("DEBUG MarshalJSON: Added ID: %s\n", *pm.Id)
 	}
 
 	// Add metadata content directly as provided by user
-	if pm.Metadata != nil && len(*pm.Metadata) > 0 {
-		normalizedMetadata := normalizeJSON(*pm.Metadata)
-		//fmt.Printf("DEBUG MarshalJSON: Metadata to add: %+v\n", normalizedMetadata)
-
-		// Add all metadata fields directly to the result
-		for key, value := range normalizedMetadata {
+	if pm.Metadata != nil {
+		for key, value := range *pm.Metadata {
 			result[key] = value
-			//fmt.Printf("DEBUG MarshalJSON: Added field %s: %+v\n", key, value)
 		}
-	} else {
-		//fmt.Printf("DEBUG MarshalJSON: No metadata to add\n")
 	}
 
-	//fmt.Printf("DEBUG MarshalJSON: Final result: %+v\n", result)
-	finalJSON, err := json.Marshal(result)
-	//fmt.Printf("DEBUG MarshalJSON: Final JSON: %s\n", string(finalJSON))
-	return finalJSON, err
+	// Metadata fields belong to the plugin, so their arrays keep the order of the user
+	return canonicalPluginJSON(result)
 }
 
 // UnmarshalJSON implements custom JSON unmarshaling
@@ -112,8 +67,7 @@ func (pm *PluginMetadata) UnmarshalJSON(data []byte) error {
 	}
 
 	if len(metadata) > 0 {
-		normalizedMetadata := normalizeJSON(metadata)
-		pm.Metadata = &normalizedMetadata
+		pm.Metadata = &metadata
 	}
 
 	return nil
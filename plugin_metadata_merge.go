@@ -0,0 +1,175 @@
+package api_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ListPlugins - Returns the names of the plugins enabled on the gateway
+func (c *ApiClient) ListPlugins() ([]string, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/plugins/list", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := []string{}
+	err = json.Unmarshal(body, &plugins)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(plugins)
+	return plugins, nil
+}
+
+// ListPluginMetadata - Returns the metadata of every enabled plugin that has metadata set
+func (c *ApiClient) ListPluginMetadata() ([]PluginMetadata, error) {
+	plugins, err := c.ListPlugins()
+	if err != nil {
+		return nil, err
+	}
+
+	metadataList := []PluginMetadata{}
+	for _, plugin := range plugins {
+		metadata, err := c.GetPluginMetadata(plugin)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get metadata of plugin %s: %w", plugin, err)
+		}
+
+		if metadata.Id == nil {
+			metadata.Id = &plugin
+		}
+		metadataList = append(metadataList, *metadata)
+	}
+
+	return metadataList, nil
+}
+
+// MergePluginMetadata - Deep-merges partial into the current metadata of a plugin. Nested
+// objects are merged key by key, arrays and scalars are replaced and nil values remove
+// the key, as in a JSON merge patch. Plugins without metadata start from an empty object.
+func (c *ApiClient) MergePluginMetadata(Id string, partial map[string]interface{}) (*PluginMetadata, error) {
+	current, err := c.currentPluginMetadata(Id)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := toJSONValue(partial)
+	if err != nil {
+		return nil, err
+	}
+	patchMap, _ := patch.(map[string]interface{})
+
+	merged := mergeJSONObjects(current, patchMap)
+	return c.UpdatePluginMetadata(Id, PluginMetadata{Metadata: &merged})
+}
+
+// RemovePluginMetadataKeys - Removes keys from the metadata of a plugin. Nested keys are
+// addressed with dotted paths such as "log_format.host". Missing keys are ignored.
+func (c *ApiClient) RemovePluginMetadataKeys(Id string, paths ...string) (*PluginMetadata, error) {
+	current, err := c.GetPluginMetadata(Id)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{}
+	if current.Metadata != nil {
+		metadata = *current.Metadata
+	}
+	for _, field := range serverManagedFields {
+		delete(metadata, field)
+	}
+
+	removed := false
+	for _, path := range paths {
+		if path == "" {
+			return nil, errors.New("metadata key path is empty")
+		}
+		if removeJSONPath(metadata, strings.Split(path, ".")) {
+			removed = true
+		}
+	}
+
+	if !removed {
+		return current, nil
+	}
+
+	return c.UpdatePluginMetadata(Id, PluginMetadata{Metadata: &metadata})
+}
+
+// currentPluginMetadata returns the metadata of a plugin without server managed fields,
+// empty when the plugin has none
+func (c *ApiClient) currentPluginMetadata(Id string) (map[string]interface{}, error) {
+	current, err := c.GetPluginMetadata(Id)
+	if IsNotFound(err) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{}
+	if current.Metadata != nil {
+		metadata = *current.Metadata
+	}
+	for _, field := range serverManagedFields {
+		delete(metadata, field)
+	}
+
+	return metadata, nil
+}
+
+// mergeJSONObjects merges patch into target following JSON merge patch rules
+func mergeJSONObjects(target, patch map[string]interface{}) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		patchObject, isObject := value.(map[string]interface{})
+		if !isObject {
+			target[key] = value
+			continue
+		}
+
+		targetObject, _ := target[key].(map[string]interface{})
+		target[key] = mergeJSONObjects(targetObject, patchObject)
+	}
+
+	return target
+}
+
+// removeJSONPath deletes the value at a key path and reports whether it existed
+func removeJSONPath(object map[string]interface{}, path []string) bool {
+	if len(path) == 1 {
+		if _, exists := object[path[0]]; !exists {
+			return false
+		}
+		delete(object, path[0])
+		return true
+	}
+
+	child, ok := object[path[0]].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	return removeJSONPath(child, path[1:])
+}
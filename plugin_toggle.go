@@ -0,0 +1,121 @@
+package api_client
+
+import (
+	"fmt"
+	"sort"
+)
+
+// pluginToggleKinds are the resource kinds whose plugins can be patched through a sub-path
+var pluginToggleKinds = map[ResourceKind]bool{
+	RouteKind:         true,
+	ServiceKind:       true,
+	PluginConfigKind:  true,
+	ConsumerGroupKind: true,
+	GlobalRuleKind:    true,
+}
+
+// SetPluginEnabled - Enables or disables a plugin of a route, service, plugin config,
+// consumer group or global rule through its _meta.disable flag, keeping its config.
+// Returns false when the plugin was already in the requested state.
+func (c *ApiClient) SetPluginEnabled(kind ResourceKind, id, pluginName string, enabled bool) (bool, error) {
+	if !pluginToggleKinds[kind] {
+		return false, fmt.Errorf("plugins of %s cannot be enabled or disabled", kind)
+	}
+
+	resource, err := c.getResource(kind, id)
+	if err != nil {
+		return false, err
+	}
+
+	return c.setResourcePluginEnabled(kind, id, resource, pluginName, enabled)
+}
+
+// SetPluginEnabledBySelector - Enables or disables a plugin on every resource of a kind
+// whose labels match all labels of the selector. Resources without the plugin are
+// skipped. Global rules have no labels and only match an empty selector. Returns the
+// IDs of the changed resources.
+func (c *ApiClient) SetPluginEnabledBySelector(kind ResourceKind, selector map[string]string, pluginName string, enabled bool) ([]string, error) {
+	if !pluginToggleKinds[kind] {
+		return nil, fmt.Errorf("plugins of %s cannot be enabled or disabled", kind)
+	}
+
+	resources, err := c.listResources(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return fmt.Sprint(resources[i]["id"]) < fmt.Sprint(resources[j]["id"])
+	})
+
+	var changed []string
+	for _, resource := range resources {
+		if !labelsMatch(resource, selector) {
+			continue
+		}
+
+		plugins, _ := resource["plugins"].(map[string]interface{})
+		if _, exists := plugins[pluginName]; !exists {
+			continue
+		}
+
+		id := fmt.Sprint(resource["id"])
+		updated, err := c.setResourcePluginEnabled(kind, id, resource, pluginName, enabled)
+		if err != nil {
+			return changed, fmt.Errorf("failed to update %s %s: %w", kind, id, err)
+		}
+		if updated {
+			changed = append(changed, id)
+		}
+	}
+
+	return changed, nil
+}
+
+func (c *ApiClient) setResourcePluginEnabled(kind ResourceKind, id string, resource map[string]interface{}, pluginName string, enabled bool) (bool, error) {
+	plugins, _ := resource["plugins"].(map[string]interface{})
+	conf, ok := plugins[pluginName].(map[string]interface{})
+	if !ok {
+		return false, fmt.Errorf("%s %s has no %s plugin", kind, id, pluginName)
+	}
+
+	meta, _ := conf["_meta"].(map[string]interface{})
+	disabled, _ := meta["disable"].(bool)
+	if disabled != enabled {
+		return false, nil
+	}
+
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+
+	if enabled {
+		delete(meta, "disable")
+	} else {
+		meta["disable"] = true
+	}
+
+	if len(meta) == 0 {
+		delete(conf, "_meta")
+	} else {
+		conf["_meta"] = meta
+	}
+
+	if _, err := c.patchResource(kind, id, "plugins/"+pluginName, conf); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// labelsMatch reports whether a resource carries every label of the selector
+func labelsMatch(resource map[string]interface{}, selector map[string]string) bool {
+	labels, _ := resource["labels"].(map[string]interface{})
+	for key, value := range selector {
+		if labelValue, _ := labels[key].(string); labelValue != value {
+			return false
+		}
+	}
+
+	return true
+}
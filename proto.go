@@ -0,0 +1,150 @@
+package api_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Proto is a protobuf definition the grpc-transcode plugin references by proto_id
+type Proto struct {
+	ID          *string `json:"id,omitempty"`
+	Description *string `json:"desc,omitempty"`
+	Content     *string `json:"content"`
+}
+
+type ProtoAPIResponse struct {
+	Key   string `json:"key"`
+	Value Proto  `json:"value"`
+}
+
+type ProtoListAPIResponse struct {
+	Total int                `json:"total"`
+	List  []ProtoAPIResponse `json:"list"`
+}
+
+// GetProto - Return a specific proto
+func (c *ApiClient) GetProto(protoID string) (*Proto, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/protos/%s", c.Endpoint, protoID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	getResponse := ProtoAPIResponse{}
+	err = json.Unmarshal(body, &getResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &getResponse.Value, nil
+}
+
+// ListProtos - Returns all protos
+func (c *ApiClient) ListProtos() ([]Proto, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/protos", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := ProtoListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	protos := make([]Proto, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		protos = append(protos, item.Value)
+	}
+
+	return protos, nil
+}
+
+// CreateProto - Creates a proto
+func (c *ApiClient) CreateProto(proto Proto) (*Proto, error) {
+	rb, err := json.Marshal(proto)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/apisix/admin/protos/", c.Endpoint), strings.NewReader(string(rb)))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	creationResponse := ProtoAPIResponse{}
+	err = json.Unmarshal(body, &creationResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &creationResponse.Value, nil
+}
+
+// UpdateProto - Updates a proto
+func (c *ApiClient) UpdateProto(protoID string, proto Proto) (*Proto, error) {
+	rb, err := json.Marshal(proto)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/apisix/admin/protos/%s", c.Endpoint, protoID), strings.NewReader(string(rb)))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	updateResponse := ProtoAPIResponse{}
+	err = json.Unmarshal(body, &updateResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &updateResponse.Value, nil
+}
+
+// DeleteProto - Deletes a proto
+func (c *ApiClient) DeleteProto(protoID string) error {
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/apisix/admin/protos/%s", c.Endpoint, protoID), nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return err
+	}
+
+	deleteResponse := DeleteResponse{}
+	err = json.Unmarshal(body, &deleteResponse)
+	if err != nil {
+		return err
+	}
+
+	if deleteResponse.Deleted != "1" {
+		return errors.New(string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,413 @@
+package api_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"sort"
+)
+
+// RedactedValue replaces sensitive values in redacted copies of resources
+const RedactedValue = "[REDACTED]"
+
+// sensitivePluginFields lists the top level fields of auth plugins that hold credentials
+var sensitivePluginFields = map[string][]string{
+	KeyAuthPlugin:   {"key"},
+	JWTAuthPlugin:   {"secret", "private_key"},
+	BasicAuthPlugin: {"password"},
+	HMACAuthPlugin:  {"secret_key"},
+}
+
+// sensitiveFields are redacted at any depth of any plugin config
+var sensitiveFields = map[string]bool{
+	"password":       true,
+	"secret":         true,
+	"secret_key":     true,
+	"client_secret":  true,
+	"private_key":    true,
+	"token":          true,
+	"access_token":   true,
+	"api_key":        true,
+	"session_secret": true,
+}
+
+// Redact - Returns a deep copy of the secret with the token redacted
+func (s VaultSecret) Redact() *VaultSecret {
+	redacted := deepCopy(s)
+	redacted.Token = redactString(redacted.Token)
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the secret with the secret access key and session token redacted
+func (s AWSSecret) Redact() *AWSSecret {
+	redacted := deepCopy(s)
+	redacted.SecretAccessKey = redactString(redacted.SecretAccessKey)
+	redacted.SessionToken = redactString(redacted.SessionToken)
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the secret with the service account private key redacted
+func (s GCPSecret) Redact() *GCPSecret {
+	redacted := deepCopy(s)
+	if redacted.AuthConfig != nil {
+		redacted.AuthConfig = redacted.AuthConfig.Redact()
+	}
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the auth config with the private key redacted
+func (a AuthConfigType) Redact() *AuthConfigType {
+	redacted := deepCopy(a)
+	redacted.PrivateKey = redactString(redacted.PrivateKey)
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the secret with well-known credential fields redacted
+func (s RawSecret) Redact() *RawSecret {
+	redacted := deepCopy(s)
+
+	value := map[string]interface{}{}
+	if err := json.Unmarshal(redacted.Value, &value); err != nil {
+		redacted.Value = nil
+		return &redacted
+	}
+
+	redactSensitiveFields(value)
+	redacted.Value, _ = json.Marshal(value)
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the certificate with the private key redacted
+func (s SSLCertificate) Redact() *SSLCertificate {
+	redacted := deepCopy(s)
+	redacted.PrivateKey = redactString(redacted.PrivateKey)
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the consumer with the credentials of its plugins redacted
+func (c Consumer) Redact() *Consumer {
+	redacted := deepCopy(c)
+	redactPlugins(redacted.Plugins)
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the route with the credentials of its plugins and the TLS
+// client key of its inline upstream redacted
+func (r Route) Redact() *Route {
+	redacted := deepCopy(r)
+	redactPlugins(redacted.Plugins)
+	if redacted.Upstream != nil {
+		redacted.Upstream = redacted.Upstream.Redact()
+	}
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the service with the credentials of its plugins and the
+// TLS client key of its inline upstream redacted
+func (s Service) Redact() *Service {
+	redacted := deepCopy(s)
+	redactPlugins(redacted.Plugins)
+	if redacted.Upstream != nil {
+		redacted.Upstream = redacted.Upstream.Redact()
+	}
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the stream route with the credentials of its plugins and
+// the TLS client key of its inline upstream redacted
+func (r StreamRoute) Redact() *StreamRoute {
+	redacted := deepCopy(r)
+	redactPlugins(redacted.Plugins)
+	if redacted.Upstream != nil {
+		redacted.Upstream = redacted.Upstream.Redact()
+	}
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the plugin config with the credentials of its plugins redacted
+func (p PluginConfig) Redact() *PluginConfig {
+	redacted := deepCopy(p)
+	redactPlugins(redacted.Plugins)
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the consumer group with the credentials of its plugins redacted
+func (g ConsumerGroup) Redact() *ConsumerGroup {
+	redacted := deepCopy(g)
+	redactPlugins(redacted.Plugins)
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the global rule with the credentials of its plugins redacted
+func (r GlobalRule) Redact() *GlobalRule {
+	redacted := deepCopy(r)
+	redactPlugins(redacted.Plugins)
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the upstream with the TLS client key redacted
+func (u Upstream) Redact() *Upstream {
+	redacted := deepCopy(u)
+	if redacted.TLS != nil {
+		redacted.TLS.ClientKey = redactString(redacted.TLS.ClientKey)
+	}
+	return &redacted
+}
+
+// Redact - Returns a deep copy of the state with the credentials and private keys of all
+// of its resources redacted
+func (s State) Redact() *State {
+	redacted := State{}
+	for _, secret := range s.Secrets {
+		secret = deepCopy(secret)
+		redactRawResource(SecretKind, secret)
+		redacted.Secrets = append(redacted.Secrets, secret)
+	}
+	redacted.Protos = deepCopy(s.Protos)
+	for _, ssl := range s.SSLs {
+		redacted.SSLs = append(redacted.SSLs, *ssl.Redact())
+	}
+	for _, upstream := range s.Upstreams {
+		redacted.Upstreams = append(redacted.Upstreams, *upstream.Redact())
+	}
+	for _, service := range s.Services {
+		redacted.Services = append(redacted.Services, *service.Redact())
+	}
+	for _, config := range s.PluginConfigs {
+		redacted.PluginConfigs = append(redacted.PluginConfigs, *config.Redact())
+	}
+	for _, group := range s.ConsumerGroups {
+		redacted.ConsumerGroups = append(redacted.ConsumerGroups, *group.Redact())
+	}
+	for _, consumer := range s.Consumers {
+		redacted.Consumers = append(redacted.Consumers, *consumer.Redact())
+	}
+	for _, route := range s.Routes {
+		redacted.Routes = append(redacted.Routes, *route.Redact())
+	}
+	for _, route := range s.StreamRoutes {
+		redacted.StreamRoutes = append(redacted.StreamRoutes, *route.Redact())
+	}
+	for _, rule := range s.GlobalRules {
+		redacted.GlobalRules = append(redacted.GlobalRules, *rule.Redact())
+	}
+	for _, metadata := range s.PluginMetadata {
+		metadata = deepCopy(metadata)
+		if metadata.Metadata != nil {
+			redactSensitiveFields(*metadata.Metadata)
+		}
+		redacted.PluginMetadata = append(redacted.PluginMetadata, metadata)
+	}
+	return &redacted
+}
+
+func (s VaultSecret) String() string    { return redactedString(s.Redact()) }
+func (s AWSSecret) String() string      { return redactedString(s.Redact()) }
+func (s GCPSecret) String() string      { return redactedString(s.Redact()) }
+func (a AuthConfigType) String() string { return redactedString(a.Redact()) }
+func (s RawSecret) String() string      { return redactedString(s.Redact()) }
+func (s SSLCertificate) String() string { return redactedString(s.Redact()) }
+func (c Consumer) String() string       { return redactedString(c.Redact()) }
+
+func (s VaultSecret) Format(f fmt.State, verb rune)    { formatRedacted(f, verb, s.String()) }
+func (s AWSSecret) Format(f fmt.State, verb rune)      { formatRedacted(f, verb, s.String()) }
+func (s GCPSecret) Format(f fmt.State, verb rune)      { formatRedacted(f, verb, s.String()) }
+func (a AuthConfigType) Format(f fmt.State, verb rune) { formatRedacted(f, verb, a.String()) }
+func (s RawSecret) Format(f fmt.State, verb rune)      { formatRedacted(f, verb, s.String()) }
+func (s SSLCertificate) Format(f fmt.State, verb rune) { formatRedacted(f, verb, s.String()) }
+func (c Consumer) Format(f fmt.State, verb rune)       { formatRedacted(f, verb, c.String()) }
+
+func (s VaultSecret) LogValue() slog.Value    { return redactedLogValue(s.Redact()) }
+func (s AWSSecret) LogValue() slog.Value      { return redactedLogValue(s.Redact()) }
+func (s GCPSecret) LogValue() slog.Value      { return redactedLogValue(s.Redact()) }
+func (a AuthConfigType) LogValue() slog.Value { return redactedLogValue(a.Redact()) }
+func (s RawSecret) LogValue() slog.Value      { return redactedLogValue(s.Redact()) }
+func (s SSLCertificate) LogValue() slog.Value { return redactedLogValue(s.Redact()) }
+func (c Consumer) LogValue() slog.Value       { return redactedLogValue(c.Redact()) }
+
+func redactString(value *string) *string {
+	if value == nil || *value == "" || IsSecretReference(*value) || IsEnvReference(*value) {
+		return value
+	}
+
+	redacted := RedactedValue
+	return &redacted
+}
+
+// redactPlugins masks credentials in a plugins map in place
+func redactPlugins(plugins *map[string]interface{}) {
+	if plugins == nil {
+		return
+	}
+
+	for name, conf := range *plugins {
+		confMap, ok := conf.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, field := range sensitivePluginFields[name] {
+			if value, ok := confMap[field].(string); ok {
+				confMap[field] = *redactString(&value)
+			}
+		}
+
+		redactSensitiveFields(confMap)
+	}
+}
+
+// redactSensitiveFields masks well-known credential fields at any depth in place
+func redactSensitiveFields(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if str, ok := item.(string); ok && sensitiveFields[key] {
+				v[key] = *redactString(&str)
+				continue
+			}
+			redactSensitiveFields(item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			redactSensitiveFields(item)
+		}
+	}
+}
+
+// redactedString renders an already redacted value as JSON
+func redactedString(redacted interface{}) string {
+	rb, err := json.Marshal(redacted)
+	if err != nil {
+		return fmt.Sprintf("%%!(error=%s)", err)
+	}
+
+	return string(rb)
+}
+
+func formatRedacted(f fmt.State, verb rune, redacted string) {
+	switch verb {
+	case 'v', 's':
+		io.WriteString(f, redacted)
+	case 'q':
+		fmt.Fprintf(f, "%q", redacted)
+	default:
+		fmt.Fprintf(f, "%%!%c(%s)", verb, redacted)
+	}
+}
+
+// redactedLogValue turns an already redacted value into a group of its JSON fields
+func redactedLogValue(redacted interface{}) slog.Value {
+	rb, err := json.Marshal(redacted)
+	if err != nil {
+		return slog.StringValue(fmt.Sprintf("!ERROR:%s", err))
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(rb, &fields); err != nil {
+		return slog.StringValue(string(rb))
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, slog.Any(key, fields[key]))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// deepCopy returns a copy of a resource that shares no pointers, slices or maps with it
+func deepCopy[T any](value T) T {
+	source := reflect.ValueOf(&value).Elem()
+	target := reflect.New(source.Type()).Elem()
+	copyValue(target, source)
+	return target.Interface().(T)
+}
+
+func copyValue(target, source reflect.Value) {
+	switch source.Kind() {
+	case reflect.Pointer:
+		if source.IsNil() {
+			return
+		}
+		target.Set(reflect.New(source.Type().Elem()))
+		copyValue(target.Elem(), source.Elem())
+	case reflect.Interface:
+		if source.IsNil() {
+			return
+		}
+		item := reflect.New(source.Elem().Type()).Elem()
+		copyValue(item, source.Elem())
+		target.Set(item)
+	case reflect.Struct:
+		for i := 0; i < source.NumField(); i++ {
+			if target.Field(i).CanSet() {
+				copyValue(target.Field(i), source.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if source.IsNil() {
+			return
+		}
+		target.Set(reflect.MakeSlice(source.Type(), source.Len(), source.Len()))
+		for i := 0; i < source.Len(); i++ {
+			copyValue(target.Index(i), source.Index(i))
+		}
+	case reflect.Map:
+		if source.IsNil() {
+			return
+		}
+		target.Set(reflect.MakeMapWithSize(source.Type(), source.Len()))
+		iter := source.MapRange()
+		for iter.Next() {
+			item := reflect.New(iter.Value().Type()).Elem()
+			copyValue(item, iter.Value())
+			target.SetMapIndex(iter.Key(), item)
+		}
+	default:
+		target.Set(source)
+	}
+}
+
+// redactRawResource masks the credentials and private keys of a resource read as a generic
+// map in place. Credentials sub-resources are redacted like consumers.
+func redactRawResource(kind ResourceKind, object map[string]interface{}) {
+	if plugins, ok := object["plugins"].(map[string]interface{}); ok {
+		redactPlugins(&plugins)
+	}
+
+	switch kind {
+	case SSLKind:
+		redactRawString(object, "key")
+		if keys, ok := object["keys"].([]interface{}); ok {
+			for i, key := range keys {
+				if str, ok := key.(string); ok {
+					keys[i] = *redactString(&str)
+				}
+			}
+		}
+	case UpstreamKind:
+		if tls, ok := object["tls"].(map[string]interface{}); ok {
+			redactRawString(tls, "client_key")
+		}
+	case SecretKind:
+		redactRawString(object, "secret_access_key")
+		redactRawString(object, "session_token")
+		redactSensitiveFields(object)
+	case PluginMetadataKind:
+		redactSensitiveFields(object)
+	}
+}
+
+func redactRawString(object map[string]interface{}, field string) {
+	if str, ok := object[field].(string); ok {
+		object[field] = *redactString(&str)
+	}
+}
@@ -0,0 +1,319 @@
+package api_client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResourceRef identifies a resource of any kind
+type ResourceRef struct {
+	Kind ResourceKind `json:"kind"`
+	ID   string       `json:"id"`
+}
+
+func (r ResourceRef) String() string {
+	return fmt.Sprintf("%s/%s", r.Kind, r.ID)
+}
+
+// Referrer is a resource referencing another one through a field such as upstream_id.
+// Fields inside arrays are written with a * segment, e.g. plugins.traffic-split.rules.*.
+type Referrer struct {
+	ResourceRef
+	Field string `json:"field"`
+	// Detachable is set when a cascading delete clears the field instead of deleting the
+	// referrer, e.g. for the group_id of a consumer
+	Detachable bool `json:"detachable,omitempty"`
+	// Blocking is set when a cascading delete can neither delete nor detach the referrer,
+	// e.g. for the proto_id of grpc-transcode
+	Blocking bool `json:"blocking,omitempty"`
+}
+
+// detachment is a referrer a cascading delete detaches from a deleted resource
+type detachment struct {
+	referrer Referrer
+	target   ResourceRef
+}
+
+// ReferenceIndex maps every resource of a gateway to the resources referencing it
+type ReferenceIndex struct {
+	referrers map[ResourceRef][]Referrer
+}
+
+// ResourceInUseError is returned when deleting a resource other resources still reference
+type ResourceInUseError struct {
+	Resource  ResourceRef
+	Referrers []Referrer
+}
+
+func (e *ResourceInUseError) Error() string {
+	referrers := make([]string, 0, len(e.Referrers))
+	for _, referrer := range e.Referrers {
+		referrers = append(referrers, fmt.Sprintf("%s (%s)", referrer.ResourceRef, referrer.Field))
+	}
+
+	return fmt.Sprintf("%s is referenced by %s", e.Resource, strings.Join(referrers, ", "))
+}
+
+type SafeDeleteOptions struct {
+	// Cascade deletes the referrers first, and their referrers in turn, instead of refusing.
+	// Referrers that only point at the resource, such as consumers of a group, routes using
+	// a plugin config and upstreams using a client certificate, are kept with the field
+	// cleared; traffic-split rules lose the weighted upstreams of a deleted upstream. The
+	// delete is still refused when a grpc-transcode plugin uses a deleted proto.
+	Cascade bool
+}
+
+type SafeDeleteReport struct {
+	// Deleted are the deleted resources in deletion order
+	Deleted []ResourceRef `json:"deleted"`
+	// Detached are the kept referrers whose field was cleared
+	Detached []Referrer `json:"detached"`
+}
+
+// BuildReferenceIndex - Reads every referencing resource of the gateway and indexes its
+// upstream_id, service_id, plugin_config_id, group_id, tls.client_cert_id, traffic-split
+// upstream_id and grpc-transcode proto_id references
+func (c *ApiClient) BuildReferenceIndex() (*ReferenceIndex, error) {
+	index := &ReferenceIndex{referrers: map[ResourceRef][]Referrer{}}
+
+	listed := map[ResourceKind][]map[string]interface{}{}
+	for _, reference := range resourceReferences {
+		resources, done := listed[reference.From]
+		if !done {
+			var err error
+			resources, err = c.listResources(reference.From)
+			if reference.From == StreamRouteKind && isStreamModeDisabled(err) {
+				resources, err = nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s: %w", reference.From, err)
+			}
+			listed[reference.From] = resources
+		}
+
+		for _, resource := range resources {
+			for _, target := range referencedIDs(resource, reference.Path) {
+				ref := ResourceRef{Kind: reference.To, ID: target}
+				index.referrers[ref] = append(index.referrers[ref], Referrer{
+					ResourceRef: ResourceRef{Kind: reference.From, ID: resourceID(reference.From, resource)},
+					Field:       strings.Join(reference.Path, "."),
+					Detachable:  reference.Detach,
+					Blocking:    reference.Block,
+				})
+			}
+		}
+	}
+
+	for ref := range index.referrers {
+		referrers := index.referrers[ref]
+		sort.Slice(referrers, func(i, j int) bool {
+			if referrers[i].Kind != referrers[j].Kind {
+				return referrers[i].Kind < referrers[j].Kind
+			}
+			return referrers[i].ID < referrers[j].ID
+		})
+	}
+
+	return index, nil
+}
+
+// Referrers - Returns the resources referencing a resource, ordered by kind and ID
+func (i *ReferenceIndex) Referrers(kind ResourceKind, id string) []Referrer {
+	return i.referrers[ResourceRef{Kind: kind, ID: id}]
+}
+
+// SafeDelete - Deletes a resource only when nothing references it, otherwise returns a
+// ResourceInUseError listing the referrers. With Cascade the referrers are deleted or
+// detached first.
+func (c *ApiClient) SafeDelete(kind ResourceKind, id string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	index, err := c.BuildReferenceIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	target := ResourceRef{Kind: kind, ID: id}
+	if referrers := index.Referrers(kind, id); len(referrers) > 0 && !options.Cascade {
+		return nil, &ResourceInUseError{Resource: target, Referrers: referrers}
+	}
+
+	order := []ResourceRef{}
+	detach := []detachment{}
+	blocked := []Referrer{}
+	visited := map[ResourceRef]bool{}
+	index.collectCascade(target, visited, &order, &detach, &blocked)
+
+	// Referrers deleted by the cascade need no detaching and block nothing
+	blocking := []Referrer{}
+	for _, referrer := range blocked {
+		if !visited[referrer.ResourceRef] {
+			blocking = append(blocking, referrer)
+		}
+	}
+	if len(blocking) > 0 {
+		return nil, &ResourceInUseError{Resource: target, Referrers: blocking}
+	}
+
+	report := &SafeDeleteReport{Deleted: []ResourceRef{}, Detached: []Referrer{}}
+	for _, d := range detach {
+		if visited[d.referrer.ResourceRef] {
+			continue
+		}
+
+		if err := c.detachReference(d.referrer, d.target.ID); err != nil {
+			return report, fmt.Errorf("failed to clear %s of %s: %w", d.referrer.Field, d.referrer.ResourceRef, err)
+		}
+		report.Detached = append(report.Detached, d.referrer)
+	}
+
+	for _, ref := range order {
+		if err := c.deleteResource(ref.Kind, ref.ID); err != nil {
+			return report, fmt.Errorf("failed to delete %s: %w", ref, err)
+		}
+		report.Deleted = append(report.Deleted, ref)
+	}
+
+	return report, nil
+}
+
+// detachReference clears the reference of a referrer to a target, dropping objects it
+// leaves empty such as tls
+func (c *ApiClient) detachReference(referrer Referrer, targetID string) error {
+	resource, err := c.getResource(referrer.Kind, referrer.ID)
+	if err != nil {
+		return err
+	}
+
+	if !detachJSONPath(resource, strings.Split(referrer.Field, "."), targetID) {
+		return nil
+	}
+
+	_, err = c.putResource(referrer.Kind, referrer.ID, resource)
+	return err
+}
+
+// detachJSONPath removes the field at a path when it holds an ID and reports whether it
+// did. Below a "*" segment the whole array element holding the field is removed, e.g. a
+// weighted upstream of traffic-split, and objects and arrays left empty are dropped.
+func detachJSONPath(object map[string]interface{}, path []string, id string) bool {
+	key := path[0]
+	if len(path) == 1 {
+		if value, _ := object[key].(string); value != id {
+			return false
+		}
+		delete(object, key)
+		return true
+	}
+
+	if path[1] != "*" {
+		child, ok := object[key].(map[string]interface{})
+		if !ok || !detachJSONPath(child, path[1:], id) {
+			return false
+		}
+		if len(child) == 0 {
+			delete(object, key)
+		}
+		return true
+	}
+
+	items, ok := object[key].([]interface{})
+	if !ok || len(path) < 3 {
+		return false
+	}
+
+	changed := false
+	kept := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		element, ok := item.(map[string]interface{})
+		if ok && detachJSONPath(element, path[2:], id) {
+			changed = true
+			if _, stillSet := element[path[2]]; !stillSet {
+				continue
+			}
+		}
+		kept = append(kept, item)
+	}
+
+	if !changed {
+		return false
+	}
+	if len(kept) == 0 {
+		delete(object, key)
+	} else {
+		object[key] = kept
+	}
+	return true
+}
+
+// SafeDeleteUpstream - Deletes an upstream no route, service, stream route or traffic-split
+// rule references
+func (c *ApiClient) SafeDeleteUpstream(upstreamID string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(UpstreamKind, upstreamID, options)
+}
+
+// SafeDeleteService - Deletes a service no route or stream route references
+func (c *ApiClient) SafeDeleteService(serviceID string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(ServiceKind, serviceID, options)
+}
+
+// SafeDeletePluginConfig - Deletes a plugin config no route references
+func (c *ApiClient) SafeDeletePluginConfig(configID string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(PluginConfigKind, configID, options)
+}
+
+// SafeDeleteConsumerGroup - Deletes a consumer group no consumer belongs to
+func (c *ApiClient) SafeDeleteConsumerGroup(groupID string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(ConsumerGroupKind, groupID, options)
+}
+
+// SafeDeleteSslCertificate - Deletes a certificate no upstream uses as TLS client certificate
+func (c *ApiClient) SafeDeleteSslCertificate(certificateID string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(SSLKind, certificateID, options)
+}
+
+// SafeDeleteProto - Deletes a proto no grpc-transcode plugin uses
+func (c *ApiClient) SafeDeleteProto(protoID string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(ProtoKind, protoID, options)
+}
+
+// SafeDeleteRoute - Deletes a route. Nothing references routes, so this never refuses.
+func (c *ApiClient) SafeDeleteRoute(routeID string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(RouteKind, routeID, options)
+}
+
+// SafeDeleteStreamRoute - Deletes a stream route. Nothing references stream routes, so this never refuses.
+func (c *ApiClient) SafeDeleteStreamRoute(routeID string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(StreamRouteKind, routeID, options)
+}
+
+// SafeDeleteConsumer - Deletes a consumer. Nothing references consumers, so this never refuses.
+func (c *ApiClient) SafeDeleteConsumer(consumerName string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(ConsumerKind, consumerName, options)
+}
+
+// SafeDeleteGlobalRule - Deletes a global rule. Nothing references global rules, so this never refuses.
+func (c *ApiClient) SafeDeleteGlobalRule(ruleID string, options SafeDeleteOptions) (*SafeDeleteReport, error) {
+	return c.SafeDelete(GlobalRuleKind, ruleID, options)
+}
+
+// collectCascade appends the referrers of a resource to delete, depth first, and then the
+// resource. Referrers to detach and referrers blocking the delete are collected separately.
+func (i *ReferenceIndex) collectCascade(ref ResourceRef, visited map[ResourceRef]bool, order *[]ResourceRef, detach *[]detachment, blocked *[]Referrer) {
+	if visited[ref] {
+		return
+	}
+	visited[ref] = true
+
+	for _, referrer := range i.referrers[ref] {
+		switch {
+		case referrer.Blocking:
+			*blocked = append(*blocked, referrer)
+		case referrer.Detachable:
+			*detach = append(*detach, detachment{referrer: referrer, target: ref})
+		default:
+			i.collectCascade(referrer.ResourceRef, visited, order, detach, blocked)
+		}
+	}
+
+	*order = append(*order, ref)
+}
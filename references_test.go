@@ -0,0 +1,251 @@
+package api_client
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReferencedIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		path     []string
+		want     []string
+	}{
+		{
+			name:     "plain field",
+			resource: `{"upstream_id":"u1"}`,
+			path:     []string{"upstream_id"},
+			want:     []string{"u1"},
+		},
+		{
+			name:     "numeric proto_id",
+			resource: `{"plugins":{"grpc-transcode":{"proto_id":1}}}`,
+			path:     grpcTranscodeProtoPath,
+			want:     []string{"1"},
+		},
+		{
+			name:     "traffic-split upstreams",
+			resource: `{"plugins":{"traffic-split":{"rules":[{"weighted_upstreams":[{"upstream_id":"u2","weight":1},{"weight":1}]},{"weighted_upstreams":[{"upstream_id":"u3"},{"upstream_id":"u2"}]}]}}}`,
+			path:     trafficSplitUpstreamPath,
+			want:     []string{"u2", "u3"},
+		},
+		{
+			name:     "unset",
+			resource: `{"uri":"/"}`,
+			path:     trafficSplitUpstreamPath,
+			want:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(tt.resource), &resource); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := referencedIDs(resource, tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("referencedIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectCascade(t *testing.T) {
+	ssl := ResourceRef{Kind: SSLKind, ID: "client"}
+	upstream := ResourceRef{Kind: UpstreamKind, ID: "u1"}
+	service := ResourceRef{Kind: ServiceKind, ID: "s1"}
+	route := ResourceRef{Kind: RouteKind, ID: "r1"}
+	pluginConfig := ResourceRef{Kind: PluginConfigKind, ID: "pc1"}
+	other := ResourceRef{Kind: RouteKind, ID: "r2"}
+	proto := ResourceRef{Kind: ProtoKind, ID: "p1"}
+
+	index := &ReferenceIndex{referrers: map[ResourceRef][]Referrer{
+		ssl:          {{ResourceRef: upstream, Field: "tls.client_cert_id", Detachable: true}},
+		upstream:     {{ResourceRef: route, Field: "upstream_id"}, {ResourceRef: service, Field: "upstream_id"}, {ResourceRef: other, Field: "plugins.traffic-split.rules.*.weighted_upstreams.*.upstream_id", Detachable: true}},
+		service:      {{ResourceRef: route, Field: "service_id"}},
+		pluginConfig: {{ResourceRef: other, Field: "plugin_config_id", Detachable: true}},
+		proto:        {{ResourceRef: other, Field: "plugins.grpc-transcode.proto_id", Blocking: true}},
+	}}
+
+	detached := func(detach []detachment) []ResourceRef {
+		refs := []ResourceRef{}
+		for _, d := range detach {
+			refs = append(refs, d.referrer.ResourceRef)
+		}
+		return refs
+	}
+
+	tests := []struct {
+		name        string
+		target      ResourceRef
+		wantOrder   []ResourceRef
+		wantDetach  []ResourceRef
+		wantBlocked int
+	}{
+		{name: "client certificate", target: ssl, wantOrder: []ResourceRef{ssl}, wantDetach: []ResourceRef{upstream}},
+		{name: "upstream", target: upstream, wantOrder: []ResourceRef{route, service, upstream}, wantDetach: []ResourceRef{other}},
+		{name: "plugin config", target: pluginConfig, wantOrder: []ResourceRef{pluginConfig}, wantDetach: []ResourceRef{other}},
+		{name: "proto", target: proto, wantOrder: []ResourceRef{proto}, wantDetach: []ResourceRef{}, wantBlocked: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order, detach, blocked := []ResourceRef{}, []detachment{}, []Referrer{}
+			index.collectCascade(test.target, map[ResourceRef]bool{}, &order, &detach, &blocked)
+
+			if !reflect.DeepEqual(order, test.wantOrder) {
+				t.Errorf("order = %v, want %v", order, test.wantOrder)
+			}
+			if got := detached(detach); !reflect.DeepEqual(got, test.wantDetach) {
+				t.Errorf("detached = %v, want %v", got, test.wantDetach)
+			}
+			if len(blocked) != test.wantBlocked {
+				t.Errorf("blocked = %v, want %d referrers", blocked, test.wantBlocked)
+			}
+		})
+	}
+}
+
+func TestDetachJSONPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		object string
+		path   string
+		want   string
+		detach bool
+	}{
+		{
+			name:   "field",
+			object: `{"uri":"/a","plugin_config_id":"pc1"}`,
+			path:   "plugin_config_id",
+			want:   `{"uri":"/a"}`,
+			detach: true,
+		},
+		{
+			name:   "other id",
+			object: `{"uri":"/a","plugin_config_id":"pc2"}`,
+			path:   "plugin_config_id",
+			want:   `{"uri":"/a","plugin_config_id":"pc2"}`,
+		},
+		{
+			name:   "emptied object",
+			object: `{"nodes":[],"tls":{"client_cert_id":"pc1"}}`,
+			path:   "tls.client_cert_id",
+			want:   `{"nodes":[]}`,
+			detach: true,
+		},
+		{
+			name:   "weighted upstream",
+			object: `{"plugins":{"traffic-split":{"rules":[{"weighted_upstreams":[{"upstream_id":"pc1","weight":1},{"weight":3}]}]}}}`,
+			path:   "plugins.traffic-split.rules.*.weighted_upstreams.*.upstream_id",
+			want:   `{"plugins":{"traffic-split":{"rules":[{"weighted_upstreams":[{"weight":3}]}]}}}`,
+			detach: true,
+		},
+		{
+			name:   "emptied rule",
+			object: `{"plugins":{"cors":{},"traffic-split":{"rules":[{"match":[],"weighted_upstreams":[{"upstream_id":"pc1","weight":1}]},{"weighted_upstreams":[{"upstream_id":"u2","weight":1}]}]}}}`,
+			path:   "plugins.traffic-split.rules.*.weighted_upstreams.*.upstream_id",
+			want:   `{"plugins":{"cors":{},"traffic-split":{"rules":[{"weighted_upstreams":[{"upstream_id":"u2","weight":1}]}]}}}`,
+			detach: true,
+		},
+		{
+			name:   "emptied plugins",
+			object: `{"uri":"/a","plugins":{"traffic-split":{"rules":[{"weighted_upstreams":[{"upstream_id":"pc1","weight":1}]}]}}}`,
+			path:   "plugins.traffic-split.rules.*.weighted_upstreams.*.upstream_id",
+			want:   `{"uri":"/a"}`,
+			detach: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var object, want map[string]interface{}
+			if err := json.Unmarshal([]byte(test.object), &object); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := detachJSONPath(object, strings.Split(test.path, "."), "pc1"); got != test.detach {
+				t.Errorf("detachJSONPath() = %v, want %v", got, test.detach)
+			}
+			if !reflect.DeepEqual(object, want) {
+				t.Errorf("object = %v, want %v", object, want)
+			}
+		})
+	}
+}
+
+func TestSafeDeleteCascadeThroughPlugins(t *testing.T) {
+	admin, client := newFakeAdmin(t)
+
+	trafficSplit := func(upstreamIDs ...string) map[string]interface{} {
+		weighted := []interface{}{}
+		for _, id := range upstreamIDs {
+			weighted = append(weighted, map[string]interface{}{"upstream_id": id, "weight": 1})
+		}
+		return map[string]interface{}{"rules": []interface{}{map[string]interface{}{"weighted_upstreams": weighted}}}
+	}
+
+	admin.set("upstreams/u1", map[string]interface{}{"id": "u1", "type": "roundrobin"})
+	admin.set("upstreams/u2", map[string]interface{}{"id": "u2", "type": "roundrobin"})
+	admin.set("routes/split", map[string]interface{}{"id": "split", "uri": "/split", "upstream_id": "u2", "plugins": map[string]interface{}{"traffic-split": trafficSplit("u1", "u2")}})
+	admin.set("services/only-u1", map[string]interface{}{"id": "only-u1", "upstream_id": "u2", "plugins": map[string]interface{}{"traffic-split": trafficSplit("u1")}})
+	admin.set("routes/direct", map[string]interface{}{"id": "direct", "uri": "/direct", "upstream_id": "u1"})
+	admin.set("protos/p1", map[string]interface{}{"id": "p1", "content": "syntax = \"proto3\";"})
+	admin.set("routes/grpc", map[string]interface{}{"id": "grpc", "uri": "/grpc", "plugins": map[string]interface{}{"grpc-transcode": map[string]interface{}{"proto_id": "p1", "service": "a.A", "method": "M"}}})
+
+	report, err := client.SafeDeleteUpstream("u1", SafeDeleteOptions{Cascade: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantDeleted := []ResourceRef{{Kind: RouteKind, ID: "direct"}, {Kind: UpstreamKind, ID: "u1"}}
+	if !reflect.DeepEqual(report.Deleted, wantDeleted) {
+		t.Errorf("deleted %v, want %v", report.Deleted, wantDeleted)
+	}
+	if len(report.Detached) != 2 {
+		t.Errorf("detached %v, want split and only-u1", report.Detached)
+	}
+
+	route := admin.get("routes/split")
+	if route == nil {
+		t.Fatal("route split was deleted")
+	}
+	if got, want := route["plugins"], map[string]interface{}{"traffic-split": trafficSplit("u2")}; mustJSON(t, got) != mustJSON(t, want) {
+		t.Errorf("plugins of split = %v, want %v", got, want)
+	}
+
+	service := admin.get("services/only-u1")
+	if service == nil {
+		t.Fatal("service only-u1 was deleted")
+	}
+	if _, set := service["plugins"]; set {
+		t.Errorf("plugins of only-u1 = %v, want the emptied traffic-split dropped", service["plugins"])
+	}
+
+	_, err = client.SafeDeleteProto("p1", SafeDeleteOptions{Cascade: true})
+	var inUse *ResourceInUseError
+	if !errors.As(err, &inUse) || len(inUse.Referrers) != 1 || inUse.Referrers[0].ID != "grpc" {
+		t.Errorf("SafeDeleteProto() error = %v, want the grpc route blocking the delete", err)
+	}
+	if admin.get("protos/p1") == nil || admin.get("routes/grpc") == nil {
+		t.Error("a refused delete changed the gateway")
+	}
+}
+
+func mustJSON(t *testing.T, value interface{}) string {
+	t.Helper()
+
+	encoded, err := CanonicalJSON(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(encoded)
+}
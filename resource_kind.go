@@ -0,0 +1,188 @@
+package api_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ResourceKind is the Admin API path segment of a resource type
+type ResourceKind string
+
+const (
+	RouteKind          ResourceKind = "routes"
+	ServiceKind        ResourceKind = "services"
+	UpstreamKind       ResourceKind = "upstreams"
+	ConsumerKind       ResourceKind = "consumers"
+	ConsumerGroupKind  ResourceKind = "consumer_groups"
+	PluginConfigKind   ResourceKind = "plugin_configs"
+	GlobalRuleKind     ResourceKind = "global_rules"
+	SSLKind            ResourceKind = "ssls"
+	StreamRouteKind    ResourceKind = "stream_routes"
+	SecretKind         ResourceKind = "secrets"
+	PluginMetadataKind ResourceKind = "plugin_metadata"
+	ProtoKind          ResourceKind = "protos"
+)
+
+// serverManagedFields are maintained by the gateway and left out when writing a resource back
+var serverManagedFields = []string{"create_time", "update_time"}
+
+type rawResourceAPIResponse struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+// getResource returns a resource as a generic map, keeping fields the typed structs do not model
+func (c *ApiClient) getResource(kind ResourceKind, id string) (map[string]interface{}, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/%s/%s", c.Endpoint, kind, id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	getResponse := rawResourceAPIResponse{}
+	err = json.Unmarshal(body, &getResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return getResponse.Value, nil
+}
+
+// putResource writes a resource fetched with getResource back, without its server managed fields
+func (c *ApiClient) putResource(kind ResourceKind, id string, value map[string]interface{}) (map[string]interface{}, error) {
+	resource := make(map[string]interface{}, len(value))
+	for key, field := range value {
+		resource[key] = field
+	}
+	for _, field := range serverManagedFields {
+		delete(resource, field)
+	}
+
+	rb, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	// Consumers are identified by the username in the body
+	url := fmt.Sprintf("%s/apisix/admin/%s/%s", c.Endpoint, kind, id)
+	if kind == ConsumerKind {
+		url = fmt.Sprintf("%s/apisix/admin/%s/", c.Endpoint, kind)
+	}
+
+	req, err := http.NewRequest("PUT", url, strings.NewReader(string(rb)))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	updateResponse := rawResourceAPIResponse{}
+	err = json.Unmarshal(body, &updateResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return updateResponse.Value, nil
+}
+
+type rawResourceListAPIResponse struct {
+	Total int                      `json:"total"`
+	List  []rawResourceAPIResponse `json:"list"`
+}
+
+// listResources returns all resources of a kind as generic maps
+func (c *ApiClient) listResources(kind ResourceKind) ([]map[string]interface{}, error) {
+	entries, err := c.listResourceEntries(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		resources = append(resources, entry.Value)
+	}
+
+	return resources, nil
+}
+
+// listResourceEntries returns all resources of a kind with their keys
+func (c *ApiClient) listResourceEntries(kind ResourceKind) ([]rawResourceAPIResponse, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/%s", c.Endpoint, kind), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := rawResourceListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return listResponse.List, nil
+}
+
+// patchResource replaces the value at a sub-path of a resource, e.g. plugins/limit-count
+func (c *ApiClient) patchResource(kind ResourceKind, id, subPath string, value interface{}) (map[string]interface{}, error) {
+	rb, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PATCH", fmt.Sprintf("%s/apisix/admin/%s/%s/%s", c.Endpoint, kind, id, subPath), strings.NewReader(string(rb)))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	patchResponse := rawResourceAPIResponse{}
+	err = json.Unmarshal(body, &patchResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return patchResponse.Value, nil
+}
+
+// deleteResource deletes a resource of any kind
+func (c *ApiClient) deleteResource(kind ResourceKind, id string) error {
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/apisix/admin/%s/%s", c.Endpoint, kind, id), nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return err
+	}
+
+	deleteResponse := DeleteResponse{}
+	err = json.Unmarshal(body, &deleteResponse)
+	if err != nil {
+		return err
+	}
+
+	if deleteResponse.Deleted != "1" {
+		return errors.New(string(body))
+	}
+
+	return nil
+}
@@ -24,6 +24,7 @@ type Route struct {
 	FilterFunc      *string                 `json:"filter_func,omitempty"`
 	Plugins         *map[string]interface{} `json:"plugins,omitempty"`
 	Script          *string                 `json:"script,omitempty"`
+	Upstream        *Upstream               `json:"upstream,omitempty"`
 	UpstreamId      *string                 `json:"upstream_id,omitempty"`
 	ServiceId       *string                 `json:"service_id,omitempty"`
 	PluginConfigId  *string                 `json:"plugin_config_id,omitempty"`
@@ -38,6 +39,11 @@ type RouteAPIResponse struct {
 	Value Route  `json:"value"`
 }
 
+type RouteListAPIResponse struct {
+	Total int                `json:"total"`
+	List  []RouteAPIResponse `json:"list"`
+}
+
 // GetRoute - Returns a specific route
 func (c *ApiClient) GetRoute(routeID string) (*Route, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/routes/%s", c.Endpoint, routeID), nil)
@@ -59,6 +65,32 @@ func (c *ApiClient) GetRoute(routeID string) (*Route, error) {
 	return &getResponse.Value, nil
 }
 
+// ListRoutes - Returns all routes
+func (c *ApiClient) ListRoutes() ([]Route, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/routes", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := RouteListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]Route, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		routes = append(routes, item.Value)
+	}
+
+	return routes, nil
+}
+
 // CreateRoute - Creates a route
 func (c *ApiClient) CreateRoute(route Route) (*Route, error) {
 	rb, err := json.Marshal(route)
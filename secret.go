@@ -20,6 +20,10 @@ func (b *BaseSecret) GetID() string {
 	return b.ID
 }
 
+func (b *BaseSecret) setID(id string) {
+	b.ID = id
+}
+
 type SecretManager string
 
 const (
@@ -53,19 +57,51 @@ type GCPSecret struct {
 }
 
 type AuthConfigType struct {
-	ClientEmail *string `json:"client_email"`
-	PrivateKey  *string `json:"private_key"`
-	ProjectId   *string `json:"project_id"`
-	TokenUri    *string `json:"token_uri,omitempty"`
-	EntriesUri  *string `json:"entries_uri,omitempty"`
+	ClientEmail *string   `json:"client_email"`
+	PrivateKey  *string   `json:"private_key"`
+	ProjectId   *string   `json:"project_id"`
+	TokenUri    *string   `json:"token_uri,omitempty"`
+	EntriesUri  *string   `json:"entries_uri,omitempty"`
 	Scope       *[]string `json:"scope,omitempty"`
 }
 
+// RawSecret keeps the configuration of a secret whose manager is not modelled by this
+// client, so it can still be listed, exported and written back unchanged
+type RawSecret struct {
+	BaseSecret
+	Manager SecretManager
+	Value   json.RawMessage
+}
+
+func (s *RawSecret) MarshalJSON() ([]byte, error) {
+	if len(s.Value) == 0 {
+		return []byte("{}"), nil
+	}
+
+	return s.Value, nil
+}
+
+func (s *RawSecret) UnmarshalJSON(data []byte) error {
+	base := BaseSecret{}
+	if err := json.Unmarshal(data, &base); err != nil {
+		return err
+	}
+
+	s.BaseSecret = base
+	s.Value = append(json.RawMessage{}, data...)
+	return nil
+}
+
 type SecretAPIResponse struct {
 	Key   string          `json:"key"`
 	Value json.RawMessage `json:"value"`
 }
 
+type SecretListAPIResponse struct {
+	Total int                 `json:"total"`
+	List  []SecretAPIResponse `json:"list"`
+}
+
 func SecretFactory(secretManager SecretManager) (Secret, error) {
 	switch secretManager {
 	case Vault:
@@ -79,6 +115,95 @@ func SecretFactory(secretManager SecretManager) (Secret, error) {
 	}
 }
 
+// SecretManagerOf - Returns the manager a secret belongs to
+func SecretManagerOf(secret Secret) SecretManager {
+	switch s := secret.(type) {
+	case *VaultSecret:
+		return Vault
+	case *AWSSecret:
+		return AWS
+	case *GCPSecret:
+		return GCP
+	case *RawSecret:
+		return s.Manager
+	default:
+		return ""
+	}
+}
+
+// decodeSecret unmarshals a secret into the type of its manager, falling back to a
+// RawSecret for managers this client does not know
+func decodeSecret(secretManager SecretManager, data json.RawMessage) (Secret, error) {
+	secret, err := SecretFactory(secretManager)
+	if err != nil {
+		secret = &RawSecret{Manager: secretManager}
+	}
+
+	err = json.Unmarshal(data, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// parseSecretKey splits a secret key such as /apisix/secrets/vault/1 into manager and ID
+func parseSecretKey(key string) (SecretManager, string, error) {
+	index := strings.Index(key, "/secrets/")
+	if index < 0 {
+		return "", "", fmt.Errorf("unexpected secret key: %s", key)
+	}
+
+	parts := strings.SplitN(key[index+len("/secrets/"):], "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("unexpected secret key: %s", key)
+	}
+
+	return SecretManager(parts[0]), parts[1], nil
+}
+
+// ListSecrets - Returns the secrets of all managers. Secrets of managers this client
+// does not model are returned as RawSecret.
+func (c *ApiClient) ListSecrets() ([]Secret, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/secrets", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := SecretListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]Secret, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		secretManager, secretID, err := parseSecretKey(item.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		secret, err := decodeSecret(secretManager, item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode secret %s/%s: %w", secretManager, secretID, err)
+		}
+
+		// The key is authoritative, the value may carry the ID prefixed by the manager
+		if idSetter, ok := secret.(interface{ setID(string) }); ok {
+			idSetter.setID(secretID)
+		}
+
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
 // GetSecret - Returns a specific secret
 func (c *ApiClient) GetSecret(secretManager SecretManager, secretID string) (Secret, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/secrets/%s/%s", c.Endpoint, secretManager, secretID), nil)
@@ -97,12 +222,7 @@ func (c *ApiClient) GetSecret(secretManager SecretManager, secretID string) (Sec
 		return nil, err
 	}
 
-	gotSecret, err := SecretFactory(secretManager)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(getResponse.Value, gotSecret)
+	gotSecret, err := decodeSecret(secretManager, getResponse.Value)
 	if err != nil {
 		return nil, err
 	}
@@ -133,12 +253,7 @@ func (c *ApiClient) CreateSecret(secretManager SecretManager, secretID string, s
 		return nil, err
 	}
 
-	createdSecret, err := SecretFactory(secretManager)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(creationResponse.Value, createdSecret)
+	createdSecret, err := decodeSecret(secretManager, creationResponse.Value)
 	if err != nil {
 		return nil, err
 	}
@@ -169,12 +284,7 @@ func (c *ApiClient) UpdateSecret(secretManager SecretManager, secretID string, s
 		return nil, err
 	}
 
-	updatedSecret, err := SecretFactory(secretManager)
-	if err != nil {
-		return nil, err
-	}
-
-	err = json.Unmarshal(updateResponse.Value, updatedSecret)
+	updatedSecret, err := decodeSecret(secretManager, updateResponse.Value)
 	if err != nil {
 		return nil, err
 	}
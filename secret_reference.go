@@ -0,0 +1,234 @@
+package api_client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	secretReferencePrefix = "$secret://"
+	envReferencePrefix    = "$env://"
+)
+
+// SecretReference points a plugin config value at a key stored in a secret manager,
+// e.g. $secret://vault/1/jwt/secret
+type SecretReference struct {
+	Manager SecretManager
+	ID      string
+	Key     string
+}
+
+// EnvReference points a plugin config value at an environment variable of the gateway,
+// e.g. $env://JWT_SECRET
+type EnvReference struct {
+	Name string
+}
+
+// Reference - Builds a reference to a key of a secret stored in this manager
+func (m SecretManager) Reference(secretID, key string) SecretReference {
+	return SecretReference{Manager: m, ID: secretID, Key: key}
+}
+
+func (r SecretReference) String() string {
+	return fmt.Sprintf("%s%s/%s/%s", secretReferencePrefix, r.Manager, r.ID, r.Key)
+}
+
+func (r EnvReference) String() string {
+	return envReferencePrefix + r.Name
+}
+
+// IsSecretReference reports whether a plugin config value is a $secret:// reference
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(value, secretReferencePrefix)
+}
+
+// IsEnvReference reports whether a plugin config value is an $env:// reference
+func IsEnvReference(value string) bool {
+	return len(value) >= len(envReferencePrefix) && strings.EqualFold(value[:len(envReferencePrefix)], envReferencePrefix)
+}
+
+// ParseSecretReference - Parses a $secret://manager/id/key reference
+func ParseSecretReference(value string) (*SecretReference, error) {
+	if !IsSecretReference(value) {
+		return nil, fmt.Errorf("not a secret reference: %s", value)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, secretReferencePrefix), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid secret reference %s, expected %smanager/id/key", value, secretReferencePrefix)
+	}
+
+	return &SecretReference{Manager: SecretManager(parts[0]), ID: parts[1], Key: parts[2]}, nil
+}
+
+// ParseEnvReference - Parses an $env://VAR reference
+func ParseEnvReference(value string) (*EnvReference, error) {
+	if !IsEnvReference(value) {
+		return nil, fmt.Errorf("not an env reference: %s", value)
+	}
+
+	name := value[len(envReferencePrefix):]
+	if name == "" {
+		return nil, fmt.Errorf("invalid env reference %s, expected %sVAR", value, envReferencePrefix)
+	}
+
+	return &EnvReference{Name: name}, nil
+}
+
+// SecretReferenceUsage is a $secret:// or $env:// reference found in a plugin config
+type SecretReferenceUsage struct {
+	Kind       ResourceKind
+	ResourceID string
+	Plugin     string
+	Path       string
+	Reference  string
+}
+
+// SecretReferenceProblem is a reference that cannot be resolved by the gateway
+type SecretReferenceProblem struct {
+	SecretReferenceUsage
+	Reason string
+}
+
+// FindSecretReferences - Returns every $secret:// and $env:// reference in the plugins
+// of routes, services, consumers and global rules
+func (c *ApiClient) FindSecretReferences() ([]SecretReferenceUsage, error) {
+	var usages []SecretReferenceUsage
+
+	routes, err := c.ListRoutes()
+	if err != nil {
+		return nil, err
+	}
+	for _, route := range routes {
+		usages = append(usages, pluginSecretReferences(RouteKind, stringValue(route.ID), route.Plugins)...)
+	}
+
+	services, err := c.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		usages = append(usages, pluginSecretReferences(ServiceKind, stringValue(service.ID), service.Plugins)...)
+	}
+
+	consumers, err := c.ListConsumers()
+	if err != nil {
+		return nil, err
+	}
+	for _, consumer := range consumers {
+		usages = append(usages, pluginSecretReferences(ConsumerKind, stringValue(consumer.Username), consumer.Plugins)...)
+	}
+
+	rules, err := c.ListGlobalRules()
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		usages = append(usages, pluginSecretReferences(GlobalRuleKind, stringValue(rule.ID), rule.Plugins)...)
+	}
+
+	return usages, nil
+}
+
+// CheckSecretReferences - Reports $secret:// references that are malformed or point at
+// a secret that does not exist. $env:// references are resolved from the gateway's
+// environment and cannot be checked remotely.
+func (c *ApiClient) CheckSecretReferences() ([]SecretReferenceProblem, error) {
+	usages, err := c.FindSecretReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	// Secret lookups are shared between references to the same secret
+	lookups := map[string]error{}
+
+	var problems []SecretReferenceProblem
+	for _, usage := range usages {
+		if !IsSecretReference(usage.Reference) {
+			continue
+		}
+
+		reference, err := ParseSecretReference(usage.Reference)
+		if err != nil {
+			problems = append(problems, SecretReferenceProblem{SecretReferenceUsage: usage, Reason: err.Error()})
+			continue
+		}
+
+		lookupKey := fmt.Sprintf("%s/%s", reference.Manager, reference.ID)
+		lookupErr, seen := lookups[lookupKey]
+		if !seen {
+			_, lookupErr = c.GetSecret(reference.Manager, reference.ID)
+			lookups[lookupKey] = lookupErr
+		}
+
+		switch {
+		case lookupErr == nil:
+		case IsNotFound(lookupErr):
+			problems = append(problems, SecretReferenceProblem{SecretReferenceUsage: usage, Reason: fmt.Sprintf("secret %s does not exist", lookupKey)})
+		default:
+			problems = append(problems, SecretReferenceProblem{SecretReferenceUsage: usage, Reason: lookupErr.Error()})
+		}
+	}
+
+	return problems, nil
+}
+
+func pluginSecretReferences(kind ResourceKind, resourceID string, plugins *map[string]interface{}) []SecretReferenceUsage {
+	if plugins == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(*plugins))
+	for name := range *plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var usages []SecretReferenceUsage
+	for _, name := range names {
+		walkStrings((*plugins)[name], "plugins."+name, func(path, value string) {
+			if IsSecretReference(value) || IsEnvReference(value) {
+				usages = append(usages, SecretReferenceUsage{
+					Kind:       kind,
+					ResourceID: resourceID,
+					Plugin:     name,
+					Path:       path,
+					Reference:  value,
+				})
+			}
+		})
+	}
+
+	return usages
+}
+
+// walkStrings calls fn for every string in a decoded JSON value, in a stable order
+func walkStrings(value interface{}, path string, fn func(path, value string)) {
+	switch v := value.(type) {
+	case string:
+		fn(path, v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkStrings(v[key], path+"."+key, fn)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkStrings(item, path+"["+strconv.Itoa(i)+"]", fn)
+		}
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
@@ -17,6 +17,7 @@ type Service struct {
 	Labels          *map[string]string      `json:"labels,omitempty"`
 	Plugins         *map[string]interface{} `json:"plugins,omitempty"`
 	UpstreamId      *string                 `json:"upstream_id,omitempty"`
+	Upstream        *Upstream               `json:"upstream,omitempty"`
 }
 
 type ServiceAPIResponse struct {
@@ -24,6 +25,11 @@ type ServiceAPIResponse struct {
 	Value Service `json:"value"`
 }
 
+type ServiceListAPIResponse struct {
+	Total int                  `json:"total"`
+	List  []ServiceAPIResponse `json:"list"`
+}
+
 // GetService- Returns a specific service
 func (c *ApiClient) GetService(serviceID string) (*Service, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/services/%s", c.Endpoint, serviceID), nil)
@@ -45,6 +51,32 @@ func (c *ApiClient) GetService(serviceID string) (*Service, error) {
 	return &getResponse.Value, nil
 }
 
+// ListServices - Returns all services
+func (c *ApiClient) ListServices() ([]Service, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/services", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := ServiceListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]Service, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		services = append(services, item.Value)
+	}
+
+	return services, nil
+}
+
 // CreateService - Creates a service
 func (c *ApiClient) CreateService(service Service) (*Service, error) {
 	rb, err := json.Marshal(service)
@@ -13,7 +13,7 @@ type SSLCertificate struct {
 	Status        *int64             `json:"status"`
 	Certificate   *string            `json:"cert"`
 	PrivateKey    *string            `json:"key"`
-	SNIs          *[]string          `json:"snis"`
+	SNIs          *[]string          `json:"snis,omitempty"`
 	Type          *string            `json:"type"`
 	ValidityStart *int64             `json:"validity_start,omitempty"`
 	ValidityEnd   *int64             `json:"validity_end,omitempty"`
@@ -25,6 +25,11 @@ type SSLCertificateAPIResponse struct {
 	Value SSLCertificate `json:"value"`
 }
 
+type SSLCertificateListAPIResponse struct {
+	Total int                         `json:"total"`
+	List  []SSLCertificateAPIResponse `json:"list"`
+}
+
 type DeleteResponse struct {
 	Key     string `json:"key"`
 	Deleted string `json:"deleted"`
@@ -51,6 +56,32 @@ func (c *ApiClient) GetSslCertificate(certificateID string) (*SSLCertificate, er
 	return &getResponse.Value, nil
 }
 
+// ListSslCertificates - Returns all certificates
+func (c *ApiClient) ListSslCertificates() ([]SSLCertificate, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/ssls", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := SSLCertificateListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := make([]SSLCertificate, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		certificates = append(certificates, item.Value)
+	}
+
+	return certificates, nil
+}
+
 // CreateSslCertificate - Create new certificate
 func (c *ApiClient) CreateSslCertificate(sslCertificate SSLCertificate) (*SSLCertificate, error) {
 	rb, err := json.Marshal(sslCertificate)
@@ -103,6 +134,32 @@ func (c *ApiClient) UpdateSslCertificate(certificateID string, sslCertificate SS
 	return &updateResponse.Value, nil
 }
 
+// SetSslCertificateStatus - Enables (1) or disables (0) a certificate without touching its other fields
+func (c *ApiClient) SetSslCertificateStatus(certificateID string, status int64) (*SSLCertificate, error) {
+	rb, err := json.Marshal(map[string]int64{"status": status})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PATCH", fmt.Sprintf("%s/apisix/admin/ssls/%s", c.Endpoint, certificateID), strings.NewReader(string(rb)))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	patchResponse := SSLCertificateAPIResponse{}
+	err = json.Unmarshal(body, &patchResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &patchResponse.Value, nil
+}
+
 // DeleteSslCertificate - Deletes a certificate
 func (c *ApiClient) DeleteSslCertificate(certificateID string) error {
 	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/apisix/admin/ssls/%s", c.Endpoint, certificateID), nil)
@@ -0,0 +1,337 @@
+package api_client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	SSLCertificateStatusDisabled int64 = 0
+	SSLCertificateStatusEnabled  int64 = 1
+)
+
+// DefaultCertificateRotationGracePeriod is how long a replaced certificate stays
+// disabled before it is deleted when RotateCertificate is used
+const DefaultCertificateRotationGracePeriod = time.Minute
+
+type CertificateRotationOptions struct {
+	// GracePeriod is the time between disabling and deleting the old certificates
+	GracePeriod time.Duration
+	// KeepReplaced returns once the old certificates are disabled, without waiting for the
+	// grace period or deleting them; FinishCertificateRotation deletes them later
+	KeepReplaced bool
+	// Labels are set on the new certificate. When nil, the labels of the replaced certificate are copied
+	Labels *map[string]string
+}
+
+type CertificateRotationResult struct {
+	NewCertificateID       string
+	Reused                 bool
+	DisabledCertificateIDs []string
+	// ReplacedCertificateIDs are all certificates the rotation deletes, including those a
+	// previous run already disabled
+	ReplacedCertificateIDs []string
+	DeletedCertificateIDs  []string
+}
+
+// RotateCertificate - Replaces the certificate serving an SNI without downtime,
+// using the default grace period
+func (c *ApiClient) RotateCertificate(sni, newCert, newKey string) (*CertificateRotationResult, error) {
+	return c.RotateCertificateWithOptions(sni, newCert, newKey, CertificateRotationOptions{
+		GracePeriod: DefaultCertificateRotationGracePeriod,
+	})
+}
+
+// RotateCertificateWithOptions - Replaces the certificate serving an SNI without downtime.
+//
+// The new certificate is uploaded as a separate SSL object and read back to make sure
+// it was stored and is valid. The certificates it replaces are then disabled through
+// their status, and deleted once the grace period has passed. If uploading, verifying
+// or disabling fails, the disabled certificates are re-enabled and a certificate
+// created by this call is removed again. Rerunning a rotation is safe: an already
+// uploaded certificate is reused and already disabled ones are only deleted.
+func (c *ApiClient) RotateCertificateWithOptions(sni, newCert, newKey string, options CertificateRotationOptions) (*CertificateRotationResult, error) {
+	return c.RotateCertificateWithContext(context.Background(), sni, newCert, newKey, options)
+}
+
+// RotateCertificateWithContext - Like RotateCertificateWithOptions, stopping when ctx is
+// done. Until the old certificates are disabled, stopping rolls back like a failure does.
+// During the grace period it returns the result with the error of ctx: the new certificate
+// serves the SNI and the old ones stay disabled until FinishCertificateRotation or a rerun
+// deletes them.
+func (c *ApiClient) RotateCertificateWithContext(ctx context.Context, sni, newCert, newKey string, options CertificateRotationOptions) (*CertificateRotationResult, error) {
+	leaf, err := parseCertificateKeyPair(newCert, newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !certificateCoversSNI(leaf, sni) {
+		return nil, fmt.Errorf("new certificate is not valid for sni %s", sni)
+	}
+
+	now := time.Now()
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return nil, fmt.Errorf("new certificate is only valid from %s to %s", leaf.NotBefore, leaf.NotAfter)
+	}
+
+	fingerprint := certificateFingerprint(leaf)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	certificates, err := c.ListSslCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	var current *SSLCertificate
+	var replaced []SSLCertificate
+	for _, certificate := range certificates {
+		if certificate.ID == nil || !certificateServesSNI(certificate, sni) {
+			continue
+		}
+
+		storedFingerprint, err := pemCertificateFingerprint(certificate.Certificate)
+		if err == nil && storedFingerprint == fingerprint {
+			certificate := certificate
+			current = &certificate
+			continue
+		}
+
+		replaced = append(replaced, certificate)
+	}
+
+	// Every SNI of a replaced certificate must still be served once it is disabled
+	snis := []string{sni}
+	for _, certificate := range replaced {
+		for _, name := range *certificate.SNIs {
+			if containsFold(snis, name) {
+				continue
+			}
+			if !certificateCoversSNI(leaf, name) {
+				return nil, fmt.Errorf("certificate %s also serves %s, which the new certificate does not cover", *certificate.ID, name)
+			}
+			snis = append(snis, name)
+		}
+	}
+
+	result := &CertificateRotationResult{}
+
+	if current != nil {
+		result.NewCertificateID = *current.ID
+		result.Reused = true
+	} else {
+		labels := options.Labels
+		if labels == nil && len(replaced) > 0 {
+			labels = replaced[0].Labels
+		}
+
+		status := SSLCertificateStatusEnabled
+		certificateType := "server"
+		created, err := c.CreateSslCertificate(SSLCertificate{
+			Status:      &status,
+			Certificate: &newCert,
+			PrivateKey:  &newKey,
+			SNIs:        &snis,
+			Type:        &certificateType,
+			Labels:      labels,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload new certificate: %w", err)
+		}
+		if created.ID == nil {
+			return nil, errors.New("failed to upload new certificate: no id returned")
+		}
+		result.NewCertificateID = *created.ID
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, c.rollbackCertificateRotation(result, nil, err)
+	}
+
+	if err := c.verifyStoredCertificate(result.NewCertificateID, fingerprint); err != nil {
+		return nil, c.rollbackCertificateRotation(result, nil, err)
+	}
+
+	if current != nil && (current.Status == nil || *current.Status != SSLCertificateStatusEnabled) {
+		if _, err := c.SetSslCertificateStatus(result.NewCertificateID, SSLCertificateStatusEnabled); err != nil {
+			return nil, c.rollbackCertificateRotation(result, nil, err)
+		}
+	}
+
+	previousStatus := map[string]int64{}
+	for _, certificate := range replaced {
+		if certificate.Status != nil && *certificate.Status == SSLCertificateStatusDisabled {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, c.rollbackCertificateRotation(result, previousStatus, err)
+		}
+
+		if _, err := c.SetSslCertificateStatus(*certificate.ID, SSLCertificateStatusDisabled); err != nil {
+			return nil, c.rollbackCertificateRotation(result, previousStatus, fmt.Errorf("failed to disable certificate %s: %w", *certificate.ID, err))
+		}
+
+		previousStatus[*certificate.ID] = SSLCertificateStatusEnabled
+		if certificate.Status != nil {
+			previousStatus[*certificate.ID] = *certificate.Status
+		}
+		result.DisabledCertificateIDs = append(result.DisabledCertificateIDs, *certificate.ID)
+	}
+
+	replacedIDs := make([]string, 0, len(replaced))
+	for _, certificate := range replaced {
+		replacedIDs = append(replacedIDs, *certificate.ID)
+	}
+	result.ReplacedCertificateIDs = replacedIDs
+
+	if options.KeepReplaced || len(replaced) == 0 {
+		return result, nil
+	}
+
+	if options.GracePeriod > 0 {
+		timer := time.NewTimer(options.GracePeriod)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return result, fmt.Errorf("rotation stopped before deleting replaced certificates: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	return result, c.FinishCertificateRotation(result)
+}
+
+// FinishCertificateRotation - Deletes the certificates a rotation replaced, once their
+// grace period has passed. The new certificate has been serving traffic meanwhile, so a
+// failed delete is reported instead of rolled back; calling it again finishes the job.
+func (c *ApiClient) FinishCertificateRotation(result *CertificateRotationResult) error {
+	for _, certificateID := range result.ReplacedCertificateIDs {
+		if containsFold(result.DeletedCertificateIDs, certificateID) {
+			continue
+		}
+
+		if err := c.DeleteSslCertificate(certificateID); err != nil && !IsNotFound(err) {
+			return fmt.Errorf("failed to delete replaced certificate %s: %w", certificateID, err)
+		}
+		result.DeletedCertificateIDs = append(result.DeletedCertificateIDs, certificateID)
+	}
+
+	return nil
+}
+
+// verifyStoredCertificate checks that the certificate stored by the gateway is the
+// uploaded one and has not expired
+func (c *ApiClient) verifyStoredCertificate(certificateID, fingerprint string) error {
+	stored, err := c.GetSslCertificate(certificateID)
+	if err != nil {
+		return fmt.Errorf("failed to read back certificate %s: %w", certificateID, err)
+	}
+
+	storedFingerprint, err := pemCertificateFingerprint(stored.Certificate)
+	if err != nil {
+		return fmt.Errorf("certificate %s stored by the gateway is invalid: %w", certificateID, err)
+	}
+
+	if storedFingerprint != fingerprint {
+		return fmt.Errorf("certificate %s stored by the gateway does not match the uploaded one", certificateID)
+	}
+
+	if stored.ValidityEnd != nil && *stored.ValidityEnd <= time.Now().Unix() {
+		return fmt.Errorf("certificate %s stored by the gateway has expired", certificateID)
+	}
+
+	return nil
+}
+
+// rollbackCertificateRotation restores the status of disabled certificates and removes
+// a certificate created during the rotation. The returned error wraps the cause and
+// any rollback failures.
+func (c *ApiClient) rollbackCertificateRotation(result *CertificateRotationResult, previousStatus map[string]int64, cause error) error {
+	errs := []error{cause}
+
+	for certificateID, status := range previousStatus {
+		if _, err := c.SetSslCertificateStatus(certificateID, status); err != nil {
+			errs = append(errs, fmt.Errorf("rollback: failed to re-enable certificate %s: %w", certificateID, err))
+		}
+	}
+
+	if !result.Reused && result.NewCertificateID != "" {
+		if err := c.DeleteSslCertificate(result.NewCertificateID); err != nil {
+			errs = append(errs, fmt.Errorf("rollback: failed to delete certificate %s: %w", result.NewCertificateID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func parseCertificateKeyPair(certPEM, keyPEM string) (*x509.Certificate, error) {
+	pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate or key: %w", err)
+	}
+
+	return x509.ParseCertificate(pair.Certificate[0])
+}
+
+func pemCertificateFingerprint(certPEM *string) (string, error) {
+	if certPEM == nil {
+		return "", errors.New("no certificate")
+	}
+
+	block, _ := pem.Decode([]byte(*certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", errors.New("no PEM encoded certificate found")
+	}
+
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return certificateFingerprint(leaf), nil
+}
+
+func certificateFingerprint(certificate *x509.Certificate) string {
+	sum := sha256.Sum256(certificate.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func certificateServesSNI(certificate SSLCertificate, sni string) bool {
+	if certificate.Type != nil && *certificate.Type != "server" {
+		return false
+	}
+
+	return certificate.SNIs != nil && containsFold(*certificate.SNIs, sni)
+}
+
+// certificateCoversSNI reports whether a certificate is valid for an SNI, which may
+// itself be a wildcard
+func certificateCoversSNI(certificate *x509.Certificate, sni string) bool {
+	if strings.HasPrefix(sni, "*.") {
+		return containsFold(certificate.DNSNames, sni)
+	}
+
+	return certificate.VerifyHostname(sni) == nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,233 @@
+package api_client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self-signed certificate and key for DNS names
+func testCertificate(t *testing.T, serial int64, dnsNames ...string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+// setupRotation stores two enabled certificates serving example.com
+func setupRotation(t *testing.T) (*fakeAdmin, *ApiClient, string, string) {
+	admin, client := newFakeAdmin(t)
+
+	for i, id := range []string{"old-1", "old-2"} {
+		oldCert, oldKey := testCertificate(t, int64(i+1), "example.com")
+		admin.set("ssls/"+id, map[string]interface{}{
+			"id": id, "cert": oldCert, "key": oldKey, "snis": []interface{}{"example.com"}, "type": "server", "status": 1,
+		})
+	}
+
+	newCert, newKey := testCertificate(t, 10, "example.com")
+	return admin, client, newCert, newKey
+}
+
+// certificateStatus returns the stored status of a certificate as text, empty when the
+// certificate does not exist
+func certificateStatus(admin *fakeAdmin, id string) string {
+	certificate := admin.get("ssls/" + id)
+	if certificate == nil {
+		return ""
+	}
+	return fmt.Sprint(certificate["status"])
+}
+
+func TestRotateCertificate(t *testing.T) {
+	admin, client, newCert, newKey := setupRotation(t)
+
+	result, err := client.RotateCertificateWithOptions("example.com", newCert, newKey, CertificateRotationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &CertificateRotationResult{
+		NewCertificateID:       "generated-1",
+		DisabledCertificateIDs: []string{"old-1", "old-2"},
+		ReplacedCertificateIDs: []string{"old-1", "old-2"},
+		DeletedCertificateIDs:  []string{"old-1", "old-2"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("RotateCertificateWithOptions() = %+v, want %+v", result, want)
+	}
+
+	stored := admin.get("ssls/generated-1")
+	if stored == nil || stored["cert"] != newCert || certificateStatus(admin, "generated-1") != "1" {
+		t.Errorf("new certificate = %v, want it stored and enabled", stored)
+	}
+	if admin.get("ssls/old-1") != nil || admin.get("ssls/old-2") != nil {
+		t.Error("replaced certificates were not deleted")
+	}
+
+	// Rerunning finds the new certificate and changes nothing
+	writes := len(admin.writes())
+	result, err = client.RotateCertificateWithOptions("example.com", newCert, newKey, CertificateRotationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Reused || result.NewCertificateID != "generated-1" || len(admin.writes()) != writes {
+		t.Errorf("rerun = %+v with writes %v, want the certificate reused without writes", result, admin.writes()[writes:])
+	}
+}
+
+func TestRotateCertificateRollback(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func(r *http.Request) bool
+	}{
+		{
+			name: "verify fails",
+			fail: func(r *http.Request) bool {
+				return r.Method == http.MethodGet && r.URL.Path == "/apisix/admin/ssls/generated-1"
+			},
+		},
+		{
+			name: "disable fails",
+			fail: func(r *http.Request) bool {
+				return r.Method == http.MethodPatch && r.URL.Path == "/apisix/admin/ssls/old-2"
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			admin, client, newCert, newKey := setupRotation(t)
+			admin.fail = test.fail
+
+			if _, err := client.RotateCertificateWithOptions("example.com", newCert, newKey, CertificateRotationOptions{}); err == nil {
+				t.Fatal("RotateCertificateWithOptions() succeeded, want an error")
+			}
+
+			if admin.get("ssls/generated-1") != nil {
+				t.Error("new certificate was not deleted")
+			}
+			for _, id := range []string{"old-1", "old-2"} {
+				if status := certificateStatus(admin, id); status != "1" {
+					t.Errorf("status of %s = %q, want it enabled again", id, status)
+				}
+			}
+		})
+	}
+}
+
+func TestRotateCertificateCancelled(t *testing.T) {
+	t.Run("during upload", func(t *testing.T) {
+		admin, client, newCert, newKey := setupRotation(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		admin.fail = func(r *http.Request) bool {
+			if r.Method == http.MethodPost {
+				cancel()
+			}
+			return false
+		}
+
+		_, err := client.RotateCertificateWithContext(ctx, "example.com", newCert, newKey, CertificateRotationOptions{})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("RotateCertificateWithContext() error = %v, want context.Canceled", err)
+		}
+
+		if admin.get("ssls/generated-1") != nil {
+			t.Error("certificate uploaded before the cancellation was not deleted")
+		}
+		for _, id := range []string{"old-1", "old-2"} {
+			if status := certificateStatus(admin, id); status != "1" {
+				t.Errorf("status of %s = %q, want it untouched", id, status)
+			}
+		}
+	})
+
+	t.Run("during grace period", func(t *testing.T) {
+		admin, client, newCert, newKey := setupRotation(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		admin.fail = func(r *http.Request) bool {
+			if r.Method == http.MethodPatch && r.URL.Path == "/apisix/admin/ssls/old-2" {
+				cancel()
+			}
+			return false
+		}
+
+		result, err := client.RotateCertificateWithContext(ctx, "example.com", newCert, newKey, CertificateRotationOptions{GracePeriod: time.Hour})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("RotateCertificateWithContext() error = %v, want context.Canceled", err)
+		}
+
+		for _, id := range []string{"old-1", "old-2"} {
+			if status := certificateStatus(admin, id); status != "0" {
+				t.Errorf("status of %s = %q, want it still disabled", id, status)
+			}
+		}
+		if certificateStatus(admin, result.NewCertificateID) != "1" {
+			t.Error("new certificate is not serving")
+		}
+
+		if err := client.FinishCertificateRotation(result); err != nil {
+			t.Fatal(err)
+		}
+		if admin.get("ssls/old-1") != nil || admin.get("ssls/old-2") != nil {
+			t.Error("FinishCertificateRotation() kept the replaced certificates")
+		}
+	})
+}
+
+func TestRotateCertificateKeepReplaced(t *testing.T) {
+	admin, client, newCert, newKey := setupRotation(t)
+
+	result, err := client.RotateCertificateWithOptions("example.com", newCert, newKey, CertificateRotationOptions{GracePeriod: time.Hour, KeepReplaced: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.DeletedCertificateIDs) != 0 || admin.get("ssls/old-1") == nil {
+		t.Errorf("result = %+v, want the replaced certificates kept", result)
+	}
+
+	if err := client.FinishCertificateRotation(result); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"old-1", "old-2"}; !reflect.DeepEqual(result.DeletedCertificateIDs, want) {
+		t.Errorf("deleted %v, want %v", result.DeletedCertificateIDs, want)
+	}
+}
@@ -0,0 +1,163 @@
+package api_client
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// StandaloneEndMarker terminates an apisix.yaml file. APISIX in standalone mode ignores
+// files without it, as they may still be being written.
+const StandaloneEndMarker = "#END"
+
+// RenderStandalone - Writes a state as an apisix.yaml file for APISIX in standalone mode,
+// ending with the #END marker. References between resources must resolve within the state,
+// as there is no Admin API to hold any other resource.
+func RenderStandalone(w io.Writer, state *State) error {
+	resources, err := state.resources()
+	if err != nil {
+		return err
+	}
+
+	if err := checkReferences(resources, nil, false); err != nil {
+		return err
+	}
+
+	objects := make(map[ResourceKind]map[string]map[string]interface{}, len(resources))
+	for kind, byID := range resources {
+		objects[kind] = make(map[string]map[string]interface{}, len(byID))
+		for id, resource := range byID {
+			object, err := canonicalObject(resource)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s %s: %w", kind, id, err)
+			}
+			objects[kind][id] = object
+		}
+	}
+
+	document := stateDocument(objects)
+	if len(state.Plugins) > 0 {
+		plugins, err := toJSONValue(state.Plugins)
+		if err != nil {
+			return err
+		}
+		document["plugins"] = plugins
+	}
+
+	buf := &bytes.Buffer{}
+	if err := encodeExport(buf, document, ExportYAML); err != nil {
+		return err
+	}
+	buf.WriteString(StandaloneEndMarker + "\n")
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// WriteStandaloneFile - Renders a state to an apisix.yaml file. The file is replaced in a
+// single rename, so a running gateway never reads it half written.
+func WriteStandaloneFile(path string, state *State) error {
+	buf := &bytes.Buffer{}
+	if err := RenderStandalone(buf, state); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), ".apisix-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(buf.Bytes()); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), path)
+}
+
+// ParseStandalone - Reads an apisix.yaml file of APISIX in standalone mode into a state.
+// Files without the #END marker are rejected as incomplete. Items without an id get the
+// one APISIX gives them from their position, e.g. arr_1 for the first route.
+func ParseStandalone(r io.Reader) (*State, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasStandaloneEndMarker(content) {
+		return nil, errors.New("apisix.yaml is incomplete: missing #END marker")
+	}
+
+	value, err := parseStateDocument(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	kinds, _ := value.(map[string]interface{})
+	for _, kind := range stateKinds {
+		items, _ := kinds[string(kind)].([]interface{})
+		for i, item := range items {
+			resource, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			// Standalone files often write numeric IDs, e.g. id: 1 or upstream_id: 1
+			for key, field := range resource {
+				if key != "id" && !strings.HasSuffix(key, "_id") {
+					continue
+				}
+				switch number := field.(type) {
+				case int:
+					resource[key] = strconv.Itoa(number)
+				case float64:
+					resource[key] = strconv.FormatFloat(number, 'f', -1, 64)
+				}
+			}
+
+			if _, set := resource["id"]; !set && resourceIDField(kind) == "id" {
+				resource["id"] = fmt.Sprintf("arr_%d", i+1)
+			}
+		}
+	}
+
+	return decodeState(value)
+}
+
+// LoadStandaloneFile - Reads an apisix.yaml file of APISIX in standalone mode
+func LoadStandaloneFile(path string) (*State, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseStandalone(file)
+}
+
+// hasStandaloneEndMarker reports whether the last non-empty line is the #END marker
+func hasStandaloneEndMarker(content []byte) bool {
+	last := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			last = line
+		}
+	}
+
+	return last == StandaloneEndMarker
+}
@@ -0,0 +1,95 @@
+package api_client
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseStandalone(t *testing.T) {
+	// The example of the APISIX standalone mode documentation
+	content := `routes:
+  -
+    uri: /hello
+    upstream:
+        nodes:
+            "127.0.0.1:1980": 1
+        type: roundrobin
+  -
+    id: 2
+    uri: /world
+    upstream_id: 1
+upstreams:
+  -
+    id: 1
+    nodes:
+      "127.0.0.1:1980": 1
+    type: roundrobin
+plugins:
+  - name: proxy-rewrite
+  - name: ip-restriction
+    stream: true
+#END
+`
+
+	state, err := ParseStandalone(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseStandalone() error = %v", err)
+	}
+
+	if len(state.Routes) != 2 || stringValue(state.Routes[0].ID) != "arr_1" || stringValue(state.Routes[1].ID) != "2" {
+		t.Fatalf("routes = %+v, want ids arr_1 and 2", state.Routes)
+	}
+	upstream := state.Routes[0].Upstream
+	if upstream == nil || upstream.Nodes == nil || len(*upstream.Nodes) != 1 || (*upstream.Nodes)[0].Port != 1980 {
+		t.Errorf("inline upstream = %+v, want one node on port 1980", upstream)
+	}
+	if stringValue(state.Routes[1].UpstreamId) != "1" {
+		t.Errorf("upstream_id = %q, want 1", stringValue(state.Routes[1].UpstreamId))
+	}
+	if len(state.Plugins) != 2 || state.Plugins[1].Stream == nil || !*state.Plugins[1].Stream {
+		t.Errorf("plugins = %+v", state.Plugins)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := RenderStandalone(buf, state); err != nil {
+		t.Fatalf("RenderStandalone() error = %v", err)
+	}
+
+	again, err := ParseStandalone(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ParseStandalone() of the rendered file error = %v", err)
+	}
+
+	rerendered := &bytes.Buffer{}
+	if err := RenderStandalone(rerendered, again); err != nil {
+		t.Fatalf("RenderStandalone() error = %v", err)
+	}
+	if rerendered.String() != buf.String() {
+		t.Errorf("render and parse are not stable:\n%s\n---\n%s", buf, rerendered)
+	}
+}
+
+func TestParseStandaloneRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "missing end marker", content: "routes:\n  - uri: /hello\n"},
+		{name: "unknown field", content: "routes:\n  - uri: /hello\n    urii: /x\n#END\n"},
+		{name: "unknown upstream field", content: "upstreams:\n  - nodes: {\"a:80\": 1}\n    type: roundrobin\n    weight: 1\n#END\n"},
+		{name: "unresolved reference", content: "routes:\n  - uri: /hello\n    upstream_id: missing\n#END\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := ParseStandalone(strings.NewReader(tt.content))
+			if err == nil && tt.name == "unresolved reference" {
+				err = RenderStandalone(&bytes.Buffer{}, state)
+			}
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
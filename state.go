@@ -0,0 +1,532 @@
+package api_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// State is the full configuration of a gateway, as described in a YAML or JSON file.
+// Every resource carries its ID; consumers are identified by their username, plugin
+// metadata by the plugin name in its id field and secrets by their manager and ID, e.g.
+// vault/1. Secrets keep the fields of their manager as-is.
+//
+//	upstreams:
+//	  - id: backend
+//	    type: roundrobin
+//	    nodes:
+//	      - {host: 10.0.0.1, port: 80, weight: 1}
+//	routes:
+//	  - id: api
+//	    uri: /api/*
+//	    upstream_id: backend
+type State struct {
+	Secrets        []map[string]interface{} `json:"secrets,omitempty"`
+	SSLs           []SSLCertificate         `json:"ssls,omitempty"`
+	Protos         []Proto                  `json:"protos,omitempty"`
+	Upstreams      []Upstream               `json:"upstreams,omitempty"`
+	Services       []Service                `json:"services,omitempty"`
+	PluginConfigs  []PluginConfig           `json:"plugin_configs,omitempty"`
+	ConsumerGroups []ConsumerGroup          `json:"consumer_groups,omitempty"`
+	Consumers      []Consumer               `json:"consumers,omitempty"`
+	Routes         []Route                  `json:"routes,omitempty"`
+	StreamRoutes   []StreamRoute            `json:"stream_routes,omitempty"`
+	GlobalRules    []GlobalRule             `json:"global_rules,omitempty"`
+	PluginMetadata []PluginMetadata         `json:"plugin_metadata,omitempty"`
+	// Plugins replaces the enabled plugins of APISIX in standalone mode. The Admin API
+	// cannot change them, so FetchState and Sync leave it out.
+	Plugins []StandalonePlugin `json:"plugins,omitempty"`
+}
+
+// StandalonePlugin is a plugin enabled by an apisix.yaml file
+type StandalonePlugin struct {
+	Name   string `json:"name"`
+	Stream *bool  `json:"stream,omitempty"`
+}
+
+// stateKinds are the kinds of a State in dependency order: every kind only references
+// kinds listed before it
+var stateKinds = []ResourceKind{
+	SecretKind,
+	SSLKind,
+	ProtoKind,
+	UpstreamKind,
+	ServiceKind,
+	PluginConfigKind,
+	ConsumerGroupKind,
+	ConsumerKind,
+	RouteKind,
+	StreamRouteKind,
+	GlobalRuleKind,
+	PluginMetadataKind,
+}
+
+// resourceReference is a field of one kind holding the ID of another. A "*" segment of the
+// path matches every element of an array.
+type resourceReference struct {
+	From ResourceKind
+	Path []string
+	To   ResourceKind
+	// Detach marks references that do not make the referrer part of the target, such as
+	// the group of a consumer: cascading deletes clear the field instead of the referrer.
+	// Fields inside arrays are removed with their array element, e.g. a weighted upstream.
+	Detach bool
+	// Block marks references the referrer cannot work without and that cannot be cleared,
+	// such as the proto of grpc-transcode: cascading deletes refuse instead
+	Block bool
+}
+
+// grpcTranscodeProtoPath is where the grpc-transcode plugin references a proto
+var grpcTranscodeProtoPath = []string{"plugins", "grpc-transcode", "proto_id"}
+
+// trafficSplitUpstreamPath is where the traffic-split plugin references upstreams
+var trafficSplitUpstreamPath = []string{"plugins", "traffic-split", "rules", "*", "weighted_upstreams", "*", "upstream_id"}
+
+var resourceReferences = []resourceReference{
+	{From: UpstreamKind, Path: []string{"tls", "client_cert_id"}, To: SSLKind, Detach: true},
+	{From: ServiceKind, Path: []string{"upstream_id"}, To: UpstreamKind},
+	{From: ServiceKind, Path: trafficSplitUpstreamPath, To: UpstreamKind, Detach: true},
+	{From: ServiceKind, Path: grpcTranscodeProtoPath, To: ProtoKind, Block: true},
+	{From: PluginConfigKind, Path: trafficSplitUpstreamPath, To: UpstreamKind, Detach: true},
+	{From: PluginConfigKind, Path: grpcTranscodeProtoPath, To: ProtoKind, Block: true},
+	{From: ConsumerGroupKind, Path: trafficSplitUpstreamPath, To: UpstreamKind, Detach: true},
+	{From: ConsumerGroupKind, Path: grpcTranscodeProtoPath, To: ProtoKind, Block: true},
+	{From: ConsumerKind, Path: []string{"group_id"}, To: ConsumerGroupKind, Detach: true},
+	{From: RouteKind, Path: []string{"upstream_id"}, To: UpstreamKind},
+	{From: RouteKind, Path: []string{"service_id"}, To: ServiceKind},
+	{From: RouteKind, Path: []string{"plugin_config_id"}, To: PluginConfigKind, Detach: true},
+	{From: RouteKind, Path: trafficSplitUpstreamPath, To: UpstreamKind, Detach: true},
+	{From: RouteKind, Path: grpcTranscodeProtoPath, To: ProtoKind, Block: true},
+	{From: StreamRouteKind, Path: []string{"upstream_id"}, To: UpstreamKind},
+	{From: StreamRouteKind, Path: []string{"service_id"}, To: ServiceKind},
+	{From: GlobalRuleKind, Path: trafficSplitUpstreamPath, To: UpstreamKind, Detach: true},
+	{From: GlobalRuleKind, Path: grpcTranscodeProtoPath, To: ProtoKind, Block: true},
+}
+
+// LoadState - Reads a State from YAML or JSON. Unknown fields are rejected so that typos
+// do not silently drop configuration; server managed fields such as create_time are ignored.
+func LoadState(r io.Reader) (*State, error) {
+	value, err := parseStateDocument(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeState(value)
+}
+
+// parseStateDocument reads a YAML or JSON state document as generic JSON values
+func parseStateDocument(r io.Reader) (interface{}, error) {
+	var document interface{}
+	if err := yaml.NewDecoder(r).Decode(&document); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+
+	return yamlToJSONValue(document)
+}
+
+// LoadStateDir - Reads a State from a directory tree written by ExportDir, holding one
+// YAML or JSON file per resource in a directory per kind, e.g. routes/api.yaml
+func LoadStateDir(dir string) (*State, error) {
+	document := map[string]interface{}{}
+
+	for _, kind := range stateKinds {
+		entries, err := os.ReadDir(filepath.Join(dir, string(kind)))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		items := []interface{}{}
+		for _, entry := range entries {
+			extension := filepath.Ext(entry.Name())
+			if entry.IsDir() || (extension != ".yaml" && extension != ".yml" && extension != ".json") {
+				continue
+			}
+
+			path := filepath.Join(dir, string(kind), entry.Name())
+			item, err := readStateFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			items = append(items, item)
+		}
+
+		document[string(kind)] = items
+	}
+
+	return decodeState(document)
+}
+
+func readStateFile(path string) (interface{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var document interface{}
+	if err := yaml.NewDecoder(file).Decode(&document); err != nil {
+		return nil, err
+	}
+
+	return yamlToJSONValue(document)
+}
+
+// decodeState decodes a generic state document into a validated State
+func decodeState(value interface{}) (*State, error) {
+	if kinds, ok := value.(map[string]interface{}); ok {
+		for _, items := range kinds {
+			list, _ := items.([]interface{})
+			for _, item := range list {
+				if resource, ok := item.(map[string]interface{}); ok {
+					for _, field := range serverManagedFields {
+						delete(resource, field)
+					}
+				}
+			}
+		}
+	}
+
+	if err := checkUpstreamFields(value); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+
+	rb, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	state := State{}
+	decoder := json.NewDecoder(bytes.NewReader(rb))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&state); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+
+	if err := state.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// checkUpstreamFields rejects unknown fields of the upstreams and inline upstreams of a
+// state document. Upstreams decode themselves, so the strict decoder of a State cannot.
+func checkUpstreamFields(document interface{}) error {
+	kinds, _ := document.(map[string]interface{})
+
+	check := func(location string, value interface{}) error {
+		upstream, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		if err := normalizeUpstreamNodes(upstream); err != nil {
+			return fmt.Errorf("%s: %w", location, err)
+		}
+
+		rb, err := json.Marshal(upstream)
+		if err != nil {
+			return err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(rb))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&plainUpstream{}); err != nil {
+			return fmt.Errorf("%s: %w", location, err)
+		}
+		return nil
+	}
+
+	upstreams, _ := kinds[string(UpstreamKind)].([]interface{})
+	for i, upstream := range upstreams {
+		if err := check(fmt.Sprintf("%s[%d]", UpstreamKind, i), upstream); err != nil {
+			return err
+		}
+	}
+
+	for _, kind := range []ResourceKind{RouteKind, ServiceKind, StreamRouteKind} {
+		items, _ := kinds[string(kind)].([]interface{})
+		for i, item := range items {
+			resource, _ := item.(map[string]interface{})
+			if err := check(fmt.Sprintf("%s[%d].upstream", kind, i), resource["upstream"]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadStateFile - Reads a State from a YAML or JSON file
+func LoadStateFile(path string) (*State, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadState(file)
+}
+
+// Validate - Checks that every resource has an ID and that no ID is used twice within a kind
+func (s *State) Validate() error {
+	_, err := s.resources()
+	return err
+}
+
+// FetchState - Reads the full configuration of the gateway. Plugin metadata is read for
+// every enabled plugin.
+func (c *ApiClient) FetchState() (*State, error) {
+	state := State{}
+	var err error
+
+	if state.Secrets, err = c.listStateSecrets(); err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	if state.SSLs, err = c.ListSslCertificates(); err != nil {
+		return nil, fmt.Errorf("failed to list ssls: %w", err)
+	}
+	if state.Protos, err = c.ListProtos(); err != nil {
+		return nil, fmt.Errorf("failed to list protos: %w", err)
+	}
+	if state.Upstreams, err = c.ListUpstreams(); err != nil {
+		return nil, fmt.Errorf("failed to list upstreams: %w", err)
+	}
+	if state.Services, err = c.ListServices(); err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	if state.PluginConfigs, err = c.ListPluginConfigs(); err != nil {
+		return nil, fmt.Errorf("failed to list plugin configs: %w", err)
+	}
+	if state.ConsumerGroups, err = c.ListConsumerGroups(); err != nil {
+		return nil, fmt.Errorf("failed to list consumer groups: %w", err)
+	}
+	if state.Consumers, err = c.ListConsumers(); err != nil {
+		return nil, fmt.Errorf("failed to list consumers: %w", err)
+	}
+	if state.Routes, err = c.ListRoutes(); err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+	if state.StreamRoutes, err = c.ListStreamRoutes(); isStreamModeDisabled(err) {
+		state.StreamRoutes, err = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stream routes: %w", err)
+	}
+	if state.GlobalRules, err = c.ListGlobalRules(); err != nil {
+		return nil, fmt.Errorf("failed to list global rules: %w", err)
+	}
+	if state.PluginMetadata, err = c.ListPluginMetadata(); err != nil {
+		return nil, fmt.Errorf("failed to list plugin metadata: %w", err)
+	}
+
+	return &state, nil
+}
+
+// listStateSecrets reads the secrets of every manager with their fields as-is, identified
+// by the manager and ID of their key
+func (c *ApiClient) listStateSecrets() ([]map[string]interface{}, error) {
+	entries, err := c.listResourceEntries(SecretKind)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		secret := entry.Value
+		if secret == nil {
+			secret = map[string]interface{}{}
+		}
+		secret["id"] = entryID(SecretKind, entry)
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
+
+// entryID returns the ID of a listed resource. Secret IDs include their manager.
+func entryID(kind ResourceKind, entry rawResourceAPIResponse) string {
+	if kind == SecretKind {
+		if id, found := strings.CutPrefix(entry.Key, "/apisix/secrets/"); found {
+			return id
+		}
+	}
+
+	return resourceID(kind, entry.Value)
+}
+
+// items returns the resources of a state per kind
+func (s *State) items() map[ResourceKind][]interface{} {
+	return map[ResourceKind][]interface{}{
+		SecretKind:         toInterfaces(s.Secrets),
+		SSLKind:            toInterfaces(s.SSLs),
+		ProtoKind:          toInterfaces(s.Protos),
+		UpstreamKind:       toInterfaces(s.Upstreams),
+		ServiceKind:        toInterfaces(s.Services),
+		PluginConfigKind:   toInterfaces(s.PluginConfigs),
+		ConsumerGroupKind:  toInterfaces(s.ConsumerGroups),
+		ConsumerKind:       toInterfaces(s.Consumers),
+		RouteKind:          toInterfaces(s.Routes),
+		StreamRouteKind:    toInterfaces(s.StreamRoutes),
+		GlobalRuleKind:     toInterfaces(s.GlobalRules),
+		PluginMetadataKind: toInterfaces(s.PluginMetadata),
+	}
+}
+
+// resources returns the resources of a state in their generic JSON form, per kind and ID
+func (s *State) resources() (map[ResourceKind]map[string]map[string]interface{}, error) {
+	resources := make(map[ResourceKind]map[string]map[string]interface{}, len(stateKinds))
+
+	items := s.items()
+	for _, kind := range stateKinds {
+		resources[kind] = map[string]map[string]interface{}{}
+
+		for i, item := range items[kind] {
+			value, err := toJSONValue(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", kind, i, err)
+			}
+
+			resource, _ := value.(map[string]interface{})
+			if resource == nil {
+				resource = map[string]interface{}{}
+			}
+			for _, field := range serverManagedFields {
+				delete(resource, field)
+			}
+			// Fields without omitempty encode as null when unset
+			for key, field := range resource {
+				if field == nil {
+					delete(resource, key)
+				}
+			}
+
+			id := resourceID(kind, resource)
+			if id == "" {
+				return nil, fmt.Errorf("%s[%d] has no %s", kind, i, resourceIDField(kind))
+			}
+			if kind == SecretKind && !strings.Contains(id, "/") {
+				return nil, fmt.Errorf("%s %s has no secret manager, e.g. vault/%s", kind, id, id)
+			}
+			if _, exists := resources[kind][id]; exists {
+				return nil, fmt.Errorf("duplicate %s %s", kind, id)
+			}
+
+			resources[kind][id] = resource
+		}
+	}
+
+	return resources, nil
+}
+
+// resourceIDField returns the field identifying a resource of a kind
+func resourceIDField(kind ResourceKind) string {
+	if kind == ConsumerKind {
+		return "username"
+	}
+	return "id"
+}
+
+func resourceID(kind ResourceKind, resource map[string]interface{}) string {
+	id, _ := resource[resourceIDField(kind)].(string)
+	return id
+}
+
+// referencedIDs returns the distinct IDs a resource holds in a reference field, in order
+func referencedIDs(resource map[string]interface{}, path []string) []string {
+	ids := []string{}
+	seen := map[string]bool{}
+	collectReferencedIDs(resource, path, func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	})
+
+	return ids
+}
+
+func collectReferencedIDs(value interface{}, path []string, add func(string)) {
+	if len(path) == 0 {
+		// proto_id may be a number
+		switch id := value.(type) {
+		case string:
+			if id != "" {
+				add(id)
+			}
+		case float64:
+			add(strconv.FormatFloat(id, 'f', -1, 64))
+		}
+		return
+	}
+
+	if path[0] == "*" {
+		items, _ := value.([]interface{})
+		for _, item := range items {
+			collectReferencedIDs(item, path[1:], add)
+		}
+		return
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	collectReferencedIDs(object[path[0]], path[1:], add)
+}
+
+func toInterfaces[T any](items []T) []interface{} {
+	result := make([]interface{}, len(items))
+	for i := range items {
+		result[i] = items[i]
+	}
+	return result
+}
+
+// yamlToJSONValue converts a decoded YAML document to values encoding/json can marshal
+func yamlToJSONValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := yamlToJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted, err := yamlToJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			object[fmt.Sprint(key)] = converted
+		}
+		return object, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := yamlToJSONValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
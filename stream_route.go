@@ -9,12 +9,16 @@ import (
 )
 
 type StreamRoute struct {
-	ID         *string `json:"id,omitempty"`
-	UpstreamId *string `json:"upstream_id,omitempty"`
-	RemoteAddr *string `json:"remote_addr,omitempty"`
-	ServerAddr *string `json:"server_addr,omitempty"`
-	ServerPort *int64  `json:"server_port,omitempty"`
-	SNI        *string `json:"sni,omitempty"`
+	ID         *string                 `json:"id,omitempty"`
+	UpstreamId *string                 `json:"upstream_id,omitempty"`
+	RemoteAddr *string                 `json:"remote_addr,omitempty"`
+	ServerAddr *string                 `json:"server_addr,omitempty"`
+	ServerPort *int64                  `json:"server_port,omitempty"`
+	SNI        *string                 `json:"sni,omitempty"`
+	ServiceId  *string                 `json:"service_id,omitempty"`
+	Upstream   *Upstream               `json:"upstream,omitempty"`
+	Plugins    *map[string]interface{} `json:"plugins,omitempty"`
+	Protocol   *map[string]interface{} `json:"protocol,omitempty"`
 }
 
 type StreamRouteAPIResponse struct {
@@ -22,6 +26,11 @@ type StreamRouteAPIResponse struct {
 	Value StreamRoute `json:"value"`
 }
 
+type StreamRouteListAPIResponse struct {
+	Total int                      `json:"total"`
+	List  []StreamRouteAPIResponse `json:"list"`
+}
+
 // GetStreamRoute - Returns a specific stream route
 func (c *ApiClient) GetStreamRoute(routeID string) (*StreamRoute, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/stream_routes/%s", c.Endpoint, routeID), nil)
@@ -43,6 +52,32 @@ func (c *ApiClient) GetStreamRoute(routeID string) (*StreamRoute, error) {
 	return &getResponse.Value, nil
 }
 
+// ListStreamRoutes - Returns all stream routes
+func (c *ApiClient) ListStreamRoutes() ([]StreamRoute, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/stream_routes", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := StreamRouteListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]StreamRoute, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		routes = append(routes, item.Value)
+	}
+
+	return routes, nil
+}
+
 // CreateStreamRoute - Creates a steam route
 func (c *ApiClient) CreateStreamRoute(route StreamRoute) (*StreamRoute, error) {
 	rb, err := json.Marshal(route)
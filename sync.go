@@ -0,0 +1,409 @@
+package api_client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type SyncAction string
+
+const (
+	SyncCreate SyncAction = "create"
+	SyncUpdate SyncAction = "update"
+	SyncDelete SyncAction = "delete"
+)
+
+// PlannedChange is a single write of a sync plan. Before is the live resource and After
+// the desired one, both as canonical JSON; they hold secrets such as private keys as-is.
+type PlannedChange struct {
+	Action SyncAction      `json:"action"`
+	Kind   ResourceKind    `json:"kind"`
+	ID     string          `json:"id"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Plan is the ordered list of changes that brings the gateway to a desired state: creates
+// and updates in dependency order, then deletes in reverse dependency order
+type Plan struct {
+	Changes []PlannedChange `json:"changes"`
+}
+
+type SyncOptions struct {
+	// SkipDeletes keeps live resources that are missing from the desired state
+	SkipDeletes bool
+	DryRun      bool
+}
+
+// Count - Returns the number of changes with an action
+func (p Plan) Count(action SyncAction) int {
+	count := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// Empty - Reports whether the gateway already matches the desired state
+func (p Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String - Returns a summary line followed by one line per change, e.g. "  + routes/api"
+func (p Plan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to delete\n", p.Count(SyncCreate), p.Count(SyncUpdate), p.Count(SyncDelete))
+
+	symbols := map[SyncAction]string{SyncCreate: "+", SyncUpdate: "~", SyncDelete: "-"}
+	for _, change := range p.Changes {
+		fmt.Fprintf(&b, "  %s %s/%s\n", symbols[change.Action], change.Kind, change.ID)
+	}
+
+	return b.String()
+}
+
+// PlanSync - Compares the desired state with the live gateway and returns the changes
+// needed to reconcile them. Every reference in the desired state must point to a resource
+// of the desired state, or of the gateway when deletes are skipped.
+func (c *ApiClient) PlanSync(desired *State, options SyncOptions) (*Plan, error) {
+	desiredResources, err := desired.resources()
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := c.FetchState()
+	if err != nil {
+		return nil, err
+	}
+
+	liveResources, err := live.resources()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkReferences(desiredResources, liveResources, options.SkipDeletes); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Changes: []PlannedChange{}}
+	for _, kind := range stateKinds {
+		for _, id := range sortedIDs(desiredResources[kind]) {
+			after, err := CanonicalJSON(desiredResources[kind][id])
+			if err != nil {
+				return nil, err
+			}
+
+			liveResource, exists := liveResources[kind][id]
+			if !exists {
+				plan.Changes = append(plan.Changes, PlannedChange{Action: SyncCreate, Kind: kind, ID: id, After: after})
+				continue
+			}
+
+			if resourceUnchanged(kind, liveResource, desiredResources[kind][id]) {
+				continue
+			}
+
+			before, err := CanonicalJSON(liveResource)
+			if err != nil {
+				return nil, err
+			}
+			plan.Changes = append(plan.Changes, PlannedChange{Action: SyncUpdate, Kind: kind, ID: id, Before: before, After: after})
+		}
+	}
+
+	if options.SkipDeletes {
+		return plan, nil
+	}
+
+	for i := len(stateKinds) - 1; i >= 0; i-- {
+		kind := stateKinds[i]
+		for _, id := range sortedIDs(liveResources[kind]) {
+			if _, exists := desiredResources[kind][id]; exists {
+				continue
+			}
+
+			before, err := CanonicalJSON(liveResources[kind][id])
+			if err != nil {
+				return nil, err
+			}
+			plan.Changes = append(plan.Changes, PlannedChange{Action: SyncDelete, Kind: kind, ID: id, Before: before})
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyPlan - Applies the changes of a plan in order and returns the applied ones. It stops
+// at the first failure, leaving the remaining changes unapplied.
+func (c *ApiClient) ApplyPlan(plan *Plan) ([]PlannedChange, error) {
+	applied := []PlannedChange{}
+	for _, change := range plan.Changes {
+		if err := c.applyChange(change); err != nil {
+			return applied, fmt.Errorf("failed to %s %s/%s: %w", change.Action, change.Kind, change.ID, err)
+		}
+		applied = append(applied, change)
+	}
+
+	return applied, nil
+}
+
+// Sync - Plans and, unless it is a dry run, applies the changes that bring the gateway to
+// the desired state
+func (c *ApiClient) Sync(desired *State, options SyncOptions) (*Plan, error) {
+	plan, err := c.PlanSync(desired, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.DryRun {
+		return plan, nil
+	}
+
+	_, err = c.ApplyPlan(plan)
+	return plan, err
+}
+
+func (c *ApiClient) applyChange(change PlannedChange) error {
+	if change.Action == SyncDelete {
+		return c.deleteStateResource(change.Kind, change.ID)
+	}
+
+	if change.Action != SyncCreate && change.Action != SyncUpdate {
+		return fmt.Errorf("unsupported action %q", change.Action)
+	}
+
+	resource, err := decodeStateResource(change)
+	if err != nil {
+		return err
+	}
+
+	switch r := resource.(type) {
+	case *map[string]interface{}:
+		_, err = c.putResource(change.Kind, change.ID, *r)
+	case *Proto:
+		_, err = c.UpdateProto(change.ID, *r)
+	case *SSLCertificate:
+		_, err = c.UpdateSslCertificate(change.ID, *r)
+	case *Upstream:
+		_, err = c.UpdateUpstream(change.ID, *r)
+	case *Service:
+		_, err = c.UpdateService(change.ID, *r)
+	case *PluginConfig:
+		_, err = c.UpdatePluginConfig(change.ID, *r)
+	case *ConsumerGroup:
+		_, err = c.UpdateConsumerGroup(change.ID, *r)
+	case *Consumer:
+		_, err = c.UpdateConsumer(*r)
+	case *Route:
+		_, err = c.UpdateRoute(change.ID, *r)
+	case *StreamRoute:
+		_, err = c.UpdateStreamRoute(change.ID, *r)
+	case *GlobalRule:
+		_, err = c.UpdateGlobalRule(change.ID, *r)
+	case *PluginMetadata:
+		_, err = c.UpdatePluginMetadata(change.ID, *r)
+	}
+
+	return err
+}
+
+func (c *ApiClient) deleteStateResource(kind ResourceKind, id string) error {
+	switch kind {
+	case SecretKind:
+		return c.deleteResource(kind, id)
+	case SSLKind:
+		return c.DeleteSslCertificate(id)
+	case ProtoKind:
+		return c.DeleteProto(id)
+	case UpstreamKind:
+		return c.DeleteUpstream(id)
+	case ServiceKind:
+		return c.DeleteService(id)
+	case PluginConfigKind:
+		return c.DeletePluginConfig(id)
+	case ConsumerGroupKind:
+		return c.DeleteConsumerGroup(id)
+	case ConsumerKind:
+		return c.DeleteConsumer(id)
+	case RouteKind:
+		return c.DeleteRoute(id)
+	case StreamRouteKind:
+		return c.DeleteStreamRoute(id)
+	case GlobalRuleKind:
+		return c.DeleteGlobalRule(id)
+	case PluginMetadataKind:
+		return c.DeletePluginMetadata(id)
+	}
+
+	return fmt.Errorf("unsupported kind %q", kind)
+}
+
+func decodeChange(change PlannedChange, resource interface{}) error {
+	if len(change.After) == 0 {
+		return fmt.Errorf("%s of %s/%s has no desired resource", change.Action, change.Kind, change.ID)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(change.After))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(resource)
+}
+
+// gatewayDefaults are the fields the gateway fills in when a resource leaves them out, with
+// the value it fills in
+var gatewayDefaults = map[ResourceKind]map[string]interface{}{
+	RouteKind:    {"status": 1, "priority": 0},
+	SSLKind:      {"status": 1, "type": "server"},
+	UpstreamKind: upstreamDefaults,
+}
+
+// gatewayComputedFields are the fields the gateway derives from other fields of a resource
+var gatewayComputedFields = map[ResourceKind][]string{
+	SSLKind: {"validity_start", "validity_end"},
+}
+
+// upstreamDefaults apply to upstreams and to the inline upstreams of routes, services and
+// stream routes
+var upstreamDefaults = map[string]interface{}{
+	"type":      "roundrobin",
+	"scheme":    "http",
+	"pass_host": "pass",
+	"hash_on":   "vars",
+}
+
+// pluginDefaults are the fields plugins fill in when their config leaves them out
+var pluginDefaults = map[string]map[string]interface{}{
+	"limit-count":    {"policy": "local", "rejected_code": 503, "key": "remote_addr", "key_type": "var", "show_limit_quota_header": true, "allow_degradation": false},
+	"limit-req":      {"policy": "local", "rejected_code": 503, "key_type": "var", "nodelay": false, "allow_degradation": false},
+	"limit-conn":     {"policy": "local", "rejected_code": 503, "key_type": "var", "only_use_default_delay": false, "allow_degradation": false},
+	"key-auth":       {"header": "apikey", "query": "apikey", "hide_credentials": false},
+	"basic-auth":     {"hide_credentials": false},
+	"jwt-auth":       {"header": "authorization", "query": "jwt", "cookie": "jwt", "hide_credentials": false, "algorithm": "HS256", "exp": 86400, "base64_secret": false, "lifetime_grace_period": 0},
+	"cors":           {"allow_origins": "*", "allow_methods": "*", "allow_headers": "*", "expose_headers": "*", "max_age": 5, "allow_credential": false},
+	"ip-restriction": {"message": "Your IP address is not allowed"},
+	"proxy-rewrite":  {"use_real_request_uri_unsafe": false},
+	"prometheus":     {"prefer_name": false},
+}
+
+// resourceUnchanged compares a live resource with the desired one as Diff does, so the
+// order of set-like fields such as methods and hosts does not matter. Fields the desired
+// resource leaves out are only ignored when the gateway fills them in itself, see
+// gatewayDefaults and pluginDefaults. SSL private keys are not returned by every gateway
+// version.
+func resourceUnchanged(kind ResourceKind, live, desired map[string]interface{}) bool {
+	normalizedLive, err := normalizeForDiff(live)
+	if err != nil {
+		return false
+	}
+	normalizedDesired, err := normalizeForDiff(desired)
+	if err != nil {
+		return false
+	}
+
+	liveObject, _ := normalizedLive.(map[string]interface{})
+	desiredObject, _ := normalizedDesired.(map[string]interface{})
+	if liveObject == nil || desiredObject == nil {
+		return reflect.DeepEqual(normalizedLive, normalizedDesired)
+	}
+
+	dropGatewayDefaults(liveObject, desiredObject, gatewayDefaults[kind], gatewayComputedFields[kind])
+
+	switch kind {
+	case RouteKind, ServiceKind, StreamRouteKind:
+		liveUpstream, _ := liveObject["upstream"].(map[string]interface{})
+		desiredUpstream, _ := desiredObject["upstream"].(map[string]interface{})
+		if liveUpstream != nil && desiredUpstream != nil {
+			dropGatewayDefaults(liveUpstream, desiredUpstream, upstreamDefaults, nil)
+		}
+	case SSLKind:
+		for _, field := range []string{"key", "keys"} {
+			if _, returned := liveObject[field]; !returned {
+				delete(desiredObject, field)
+			}
+		}
+	}
+
+	livePlugins, _ := liveObject["plugins"].(map[string]interface{})
+	desiredPlugins, _ := desiredObject["plugins"].(map[string]interface{})
+	for name, liveConf := range livePlugins {
+		liveConfObject, _ := liveConf.(map[string]interface{})
+		desiredConfObject, _ := desiredPlugins[name].(map[string]interface{})
+		if liveConfObject != nil && desiredConfObject != nil {
+			dropGatewayDefaults(liveConfObject, desiredConfObject, pluginDefaults[name], nil)
+		}
+	}
+
+	return reflect.DeepEqual(liveObject, desiredObject)
+}
+
+// dropGatewayDefaults removes the fields of a live object the desired one leaves out, when
+// the gateway filled them in
+func dropGatewayDefaults(live, desired map[string]interface{}, defaults map[string]interface{}, computed []string) {
+	for field, value := range defaults {
+		if _, set := desired[field]; set {
+			continue
+		}
+		if sameJSON(live[field], value) {
+			delete(live, field)
+		}
+	}
+
+	for _, field := range computed {
+		if _, set := desired[field]; !set {
+			delete(live, field)
+		}
+	}
+}
+
+// sameJSON reports whether two values encode to the same canonical JSON
+func sameJSON(a, b interface{}) bool {
+	encodedA, err := CanonicalJSON(a)
+	if err != nil {
+		return false
+	}
+	encodedB, err := CanonicalJSON(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(encodedA, encodedB)
+}
+
+// checkReferences verifies that every reference of the desired state can be resolved
+func checkReferences(desired, live map[ResourceKind]map[string]map[string]interface{}, includeLive bool) error {
+	for _, reference := range resourceReferences {
+		// Skip kinds a State does not manage
+		if _, managed := desired[reference.To]; !managed {
+			continue
+		}
+
+		for _, id := range sortedIDs(desired[reference.From]) {
+			for _, target := range referencedIDs(desired[reference.From][id], reference.Path) {
+				if _, exists := desired[reference.To][target]; exists {
+					continue
+				}
+				if _, exists := live[reference.To][target]; exists && includeLive {
+					continue
+				}
+
+				return fmt.Errorf("%s %s references missing %s %s in %s", reference.From, id, reference.To, target, strings.Join(reference.Path, "."))
+			}
+		}
+	}
+
+	return nil
+}
+
+func sortedIDs(resources map[string]map[string]interface{}) []string {
+	ids := make([]string, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
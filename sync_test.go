@@ -0,0 +1,163 @@
+package api_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestResourceUnchanged(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      ResourceKind
+		live      string
+		desired   string
+		unchanged bool
+	}{
+		{
+			name:      "plugin defaults filled in by the gateway",
+			kind:      RouteKind,
+			live:      `{"id":"r1","uri":"/a","status":1,"plugins":{"limit-count":{"count":10,"time_window":60,"policy":"local","rejected_code":503}}}`,
+			desired:   `{"id":"r1","uri":"/a","plugins":{"limit-count":{"count":10,"time_window":60}}}`,
+			unchanged: true,
+		},
+		{
+			name:    "changed plugin field",
+			kind:    RouteKind,
+			live:    `{"id":"r1","uri":"/a","plugins":{"limit-count":{"count":10,"time_window":60,"policy":"local"}}}`,
+			desired: `{"id":"r1","uri":"/a","plugins":{"limit-count":{"count":20,"time_window":60}}}`,
+		},
+		{
+			name:    "removed plugin",
+			kind:    RouteKind,
+			live:    `{"id":"r1","uri":"/a","plugins":{"cors":{},"limit-count":{"count":10}}}`,
+			desired: `{"id":"r1","uri":"/a","plugins":{"limit-count":{"count":10}}}`,
+		},
+		{
+			name:    "removed top level object",
+			kind:    RouteKind,
+			live:    `{"id":"r1","uri":"/a","hosts":["a.example.com"]}`,
+			desired: `{"id":"r1","uri":"/a"}`,
+		},
+		{
+			name:      "reordered set-like field",
+			kind:      RouteKind,
+			live:      `{"id":"r1","uris":["/a","/b"],"methods":["GET","POST"]}`,
+			desired:   `{"id":"r1","uris":["/b","/a"],"methods":["POST","GET"]}`,
+			unchanged: true,
+		},
+		{
+			name:    "reordered ordered array",
+			kind:    UpstreamKind,
+			live:    `{"id":"u1","nodes":[{"host":"a","port":80,"weight":1},{"host":"b","port":80,"weight":1}]}`,
+			desired: `{"id":"u1","nodes":[{"host":"b","port":80,"weight":1},{"host":"a","port":80,"weight":1}]}`,
+		},
+		{
+			name:    "reordered array in plugin config",
+			kind:    RouteKind,
+			live:    `{"id":"r1","uri":"/a","plugins":{"ip-restriction":{"whitelist":["10.0.0.1","10.0.0.2"]}}}`,
+			desired: `{"id":"r1","uri":"/a","plugins":{"ip-restriction":{"whitelist":["10.0.0.2","10.0.0.1"]}}}`,
+		},
+		{
+			name:    "removed scalar field",
+			kind:    RouteKind,
+			live:    `{"id":"r1","uri":"/a","host":"a.example.com","desc":"old"}`,
+			desired: `{"id":"r1","uri":"/a"}`,
+		},
+		{
+			name:    "removed reference",
+			kind:    RouteKind,
+			live:    `{"id":"r1","uri":"/a","service_id":"s1"}`,
+			desired: `{"id":"r1","uri":"/a"}`,
+		},
+		{
+			name:    "removed consumer group",
+			kind:    ConsumerKind,
+			live:    `{"username":"jack","group_id":"g1"}`,
+			desired: `{"username":"jack"}`,
+		},
+		{
+			name:    "default field with another value",
+			kind:    RouteKind,
+			live:    `{"id":"r1","uri":"/a","status":0,"plugins":{"limit-count":{"count":10,"policy":"redis"}}}`,
+			desired: `{"id":"r1","uri":"/a","plugins":{"limit-count":{"count":10}}}`,
+		},
+		{
+			name:      "inline upstream defaults",
+			kind:      ServiceKind,
+			live:      `{"id":"s1","upstream":{"type":"roundrobin","scheme":"http","pass_host":"pass","nodes":[{"host":"a","port":80,"weight":1}]}}`,
+			desired:   `{"id":"s1","upstream":{"nodes":[{"host":"a","port":80,"weight":1}]}}`,
+			unchanged: true,
+		},
+		{
+			name:      "server managed and computed fields",
+			kind:      SSLKind,
+			live:      `{"id":"s1","cert":"CERT","snis":["a.example.com"],"status":1,"type":"server","validity_start":1,"validity_end":2,"create_time":3,"update_time":4}`,
+			desired:   `{"id":"s1","cert":"CERT","snis":["a.example.com"]}`,
+			unchanged: true,
+		},
+		{
+			name:      "SSL key not returned",
+			kind:      SSLKind,
+			live:      `{"id":"s1","cert":"CERT","snis":["a.example.com"]}`,
+			desired:   `{"id":"s1","cert":"CERT","key":"KEY","snis":["a.example.com"]}`,
+			unchanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var live, desired map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.live), &live); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.desired), &desired); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := resourceUnchanged(tt.kind, live, desired); got != tt.unchanged {
+				t.Errorf("resourceUnchanged() = %v, want %v", got, tt.unchanged)
+			}
+		})
+	}
+}
+
+func TestCheckReferences(t *testing.T) {
+	decode := func(document string) map[ResourceKind]map[string]map[string]interface{} {
+		state, err := LoadState(strings.NewReader(document))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resources, err := state.resources()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resources
+	}
+
+	live := decode("upstreams:\n  - {id: live, type: roundrobin}\n")
+	trafficSplit := "routes:\n  - id: r1\n    uri: /\n    plugins:\n      traffic-split:\n        rules:\n          - weighted_upstreams:\n              - {upstream_id: u1, weight: 1}\n              - {upstream_id: %s, weight: 1}\n"
+	upstream := "upstreams:\n  - {id: u1, type: roundrobin}\n"
+
+	tests := []struct {
+		name        string
+		desired     string
+		includeLive bool
+		wantErr     bool
+	}{
+		{name: "resolved", desired: upstream + fmt.Sprintf(trafficSplit, "u1")},
+		{name: "second traffic-split upstream missing", desired: upstream + fmt.Sprintf(trafficSplit, "u2"), wantErr: true},
+		{name: "live upstream when deletes are skipped", desired: upstream + fmt.Sprintf(trafficSplit, "live"), includeLive: true},
+		{name: "live upstream when it gets deleted", desired: upstream + fmt.Sprintf(trafficSplit, "live"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReferences(decode(tt.desired), live, tt.includeLive)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkReferences() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
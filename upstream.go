@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -98,16 +101,45 @@ type UpstreamChecksPassiveUnhealthyType struct {
 }
 
 type UpstreamNodeType struct {
-	Host   string `json:"host"`
-	Port   int64  `json:"port"`
-	Weight int64  `json:"weight"`
+	Host     string `json:"host"`
+	Port     int64  `json:"port"`
+	Weight   int64  `json:"weight"`
+	Priority *int64 `json:"priority,omitempty"`
 }
 
+// UnmarshalJSON - Decodes an upstream, accepting nodes in the map form the Admin API and
+// standalone files allow, e.g. {"10.0.0.1:80": 1}
+func (u *Upstream) UnmarshalJSON(data []byte) error {
+	value := map[string]interface{}{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	if err := normalizeUpstreamNodes(value); err != nil {
+		return err
+	}
+
+	rb, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(rb, (*plainUpstream)(u))
+}
+
+// plainUpstream decodes an upstream without its UnmarshalJSON
+type plainUpstream Upstream
+
 type UpstreamAPIResponse struct {
 	Key   string   `json:"key"`
 	Value Upstream `json:"value"`
 }
 
+type UpstreamListAPIResponse struct {
+	Total int                   `json:"total"`
+	List  []UpstreamAPIResponse `json:"list"`
+}
+
 // GetUpstream - Return a specific upstream
 func (c *ApiClient) GetUpstream(upstreamID string) (*Upstream, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/upstreams/%s", c.Endpoint, upstreamID), nil)
@@ -129,6 +161,32 @@ func (c *ApiClient) GetUpstream(upstreamID string) (*Upstream, error) {
 	return &getResponse.Value, nil
 }
 
+// ListUpstreams - Returns all upstreams
+func (c *ApiClient) ListUpstreams() ([]Upstream, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/apisix/admin/upstreams", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	listResponse := UpstreamListAPIResponse{}
+	err = json.Unmarshal(body, &listResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreams := make([]Upstream, 0, len(listResponse.List))
+	for _, item := range listResponse.List {
+		upstreams = append(upstreams, item.Value)
+	}
+
+	return upstreams, nil
+}
+
 // CreateUpstream - Create an upstream
 func (c *ApiClient) CreateUpstream(upstream Upstream) (*Upstream, error) {
 	rb, err := json.Marshal(upstream)
@@ -205,3 +263,36 @@ func (c *ApiClient) DeleteUpstream(upstreamID string) error {
 
 	return nil
 }
+
+// normalizeUpstreamNodes rewrites map form nodes of a generic upstream in place as a list
+// ordered by address
+func normalizeUpstreamNodes(upstream map[string]interface{}) error {
+	nodes, ok := upstream["nodes"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	addresses := make([]string, 0, len(nodes))
+	for address := range nodes {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	list := make([]interface{}, 0, len(nodes))
+	for _, address := range addresses {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return fmt.Errorf("invalid upstream node %q: %w", address, err)
+		}
+
+		portNumber, err := strconv.ParseInt(port, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid upstream node %q: %w", address, err)
+		}
+
+		list = append(list, map[string]interface{}{"host": host, "port": portNumber, "weight": nodes[address]})
+	}
+
+	upstream["nodes"] = list
+	return nil
+}
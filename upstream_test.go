@@ -0,0 +1,53 @@
+package api_client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUpstreamUnmarshalJSONNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []UpstreamNodeType
+		wantErr bool
+	}{
+		{
+			name:  "list form",
+			input: `{"type":"roundrobin","nodes":[{"host":"10.0.0.1","port":80,"weight":1}]}`,
+			want:  []UpstreamNodeType{{Host: "10.0.0.1", Port: 80, Weight: 1}},
+		},
+		{
+			name:  "map form ordered by address",
+			input: `{"type":"roundrobin","nodes":{"10.0.0.2:8080":2,"10.0.0.1:80":1,"[::1]:81":3}}`,
+			want: []UpstreamNodeType{
+				{Host: "10.0.0.1", Port: 80, Weight: 1},
+				{Host: "10.0.0.2", Port: 8080, Weight: 2},
+				{Host: "::1", Port: 81, Weight: 3},
+			},
+		},
+		{
+			name:    "map form without port",
+			input:   `{"type":"roundrobin","nodes":{"10.0.0.1":1}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := Upstream{}
+			err := json.Unmarshal([]byte(tt.input), &upstream)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if upstream.Nodes == nil || !reflect.DeepEqual(*upstream.Nodes, tt.want) {
+				t.Errorf("Nodes = %+v, want %+v", upstream.Nodes, tt.want)
+			}
+		})
+	}
+}